@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// CovarianceType selects how much structure each mixture component's covariance may have
+type CovarianceType string
+
+const (
+	DiagonalCovariance CovarianceType = "diag"
+	FullCovariance     CovarianceType = "full"
+)
+
+// covarianceFloor is added to every variance so components cannot collapse onto a single point
+const covarianceFloor = 1e-6
+
+// GaussianMixture is a fitted mixture of multivariate Gaussians
+type GaussianMixture struct {
+	Weights        []float64      `json:"weights"`
+	Means          [][]float64    `json:"means"`
+	Covariances    [][][]float64  `json:"covariances"`
+	CovarianceType CovarianceType `json:"covarianceType"`
+	LogLikelihood  float64        `json:"logLikelihood"`
+	History        []float64      `json:"history"`
+	Iterations     int            `json:"iterations"`
+	Converged      bool           `json:"converged"`
+}
+
+// FitGMM fits a Gaussian mixture with k components using expectation-maximisation.
+// It stops when the log-likelihood improves by less than tol or after maxIter rounds,
+// recording the log-likelihood of every round in History so convergence can be followed.
+func FitGMM(points [][]float64, k int, covType CovarianceType, maxIter int, tol float64, seed int64) (*GaussianMixture, error) {
+	n := len(points)
+	if k < 1 || k > n {
+		return nil, fmt.Errorf("number of components must be between 1 and %d, got %d", n, k)
+	}
+	if covType != DiagonalCovariance && covType != FullCovariance {
+		return nil, fmt.Errorf("unknown covariance type %q (use diag or full)", covType)
+	}
+	dim := len(points[0])
+
+	gmm := &GaussianMixture{
+		Weights:        make([]float64, k),
+		Means:          make([][]float64, k),
+		Covariances:    make([][][]float64, k),
+		CovarianceType: covType,
+	}
+
+	// Start every component on a distinct random point with the overall per-column variance
+	rng := rand.New(rand.NewSource(seed))
+	overall := columnVariances(points)
+	for c, idx := range rng.Perm(n)[:k] {
+		gmm.Weights[c] = 1.0 / float64(k)
+		gmm.Means[c] = append([]float64{}, points[idx]...)
+		gmm.Covariances[c] = make([][]float64, dim)
+		for d := range gmm.Covariances[c] {
+			gmm.Covariances[c][d] = make([]float64, dim)
+			gmm.Covariances[c][d][d] = overall[d] + covarianceFloor
+		}
+	}
+
+	resp := make([][]float64, n)
+	for i := range resp {
+		resp[i] = make([]float64, k)
+	}
+
+	prevLL := math.Inf(-1)
+	for iter := 1; iter <= maxIter; iter++ {
+		// E-step: responsibilities from log densities, normalised with log-sum-exp
+		ll, err := gmm.responsibilities(points, resp)
+		if err != nil {
+			return nil, err
+		}
+		gmm.LogLikelihood = ll
+		gmm.Iterations = iter
+		gmm.History = append(gmm.History, ll)
+
+		if math.Abs(ll-prevLL) < tol {
+			gmm.Converged = true
+			break
+		}
+		prevLL = ll
+
+		// M-step: re-estimate weights, means and covariances from the responsibilities
+		for c := 0; c < k; c++ {
+			nk := 0.0
+			mean := make([]float64, dim)
+			for i, p := range points {
+				nk += resp[i][c]
+				for d := range p {
+					mean[d] += resp[i][c] * p[d]
+				}
+			}
+			if nk == 0 {
+				continue // component lost all its points; keep its previous parameters
+			}
+			for d := range mean {
+				mean[d] /= nk
+			}
+
+			cov := make([][]float64, dim)
+			for a := range cov {
+				cov[a] = make([]float64, dim)
+			}
+			for i, p := range points {
+				for a := 0; a < dim; a++ {
+					da := p[a] - mean[a]
+					if covType == DiagonalCovariance {
+						cov[a][a] += resp[i][c] * da * da
+						continue
+					}
+					for b := 0; b <= a; b++ {
+						cov[a][b] += resp[i][c] * da * (p[b] - mean[b])
+					}
+				}
+			}
+			for a := 0; a < dim; a++ {
+				for b := 0; b <= a; b++ {
+					cov[a][b] /= nk
+					cov[b][a] = cov[a][b]
+				}
+				cov[a][a] += covarianceFloor
+			}
+
+			gmm.Weights[c] = nk / float64(n)
+			gmm.Means[c] = mean
+			gmm.Covariances[c] = cov
+		}
+	}
+
+	return gmm, nil
+}
+
+// Predict returns the soft membership of every point in each component and the hard assignment
+func (g *GaussianMixture) Predict(points [][]float64) ([][]float64, []int, error) {
+	resp := make([][]float64, len(points))
+	for i := range resp {
+		resp[i] = make([]float64, len(g.Weights))
+	}
+	if _, err := g.responsibilities(points, resp); err != nil {
+		return nil, nil, err
+	}
+
+	labels := make([]int, len(points))
+	for i, r := range resp {
+		for c := range r {
+			if r[c] > r[labels[i]] {
+				labels[i] = c
+			}
+		}
+	}
+	return resp, labels, nil
+}
+
+// responsibilities fills resp with the posterior probability of each component for every point
+// and returns the total log-likelihood of the data under the mixture
+func (g *GaussianMixture) responsibilities(points [][]float64, resp [][]float64) (float64, error) {
+	k := len(g.Weights)
+	chols := make([][][]float64, k)
+	logDets := make([]float64, k)
+	for c := 0; c < k; c++ {
+		chol, err := cholesky(g.Covariances[c])
+		if err != nil {
+			return 0, fmt.Errorf("component %d: %v", c, err)
+		}
+		chols[c] = chol
+		for d := range chol {
+			logDets[c] += 2 * math.Log(chol[d][d])
+		}
+	}
+
+	total := 0.0
+	for i, p := range points {
+		maxLog := math.Inf(-1)
+		for c := 0; c < k; c++ {
+			resp[i][c] = math.Log(g.Weights[c]) + logGaussian(p, g.Means[c], chols[c], logDets[c])
+			maxLog = math.Max(maxLog, resp[i][c])
+		}
+
+		sum := 0.0
+		for c := 0; c < k; c++ {
+			resp[i][c] = math.Exp(resp[i][c] - maxLog)
+			sum += resp[i][c]
+		}
+		for c := 0; c < k; c++ {
+			resp[i][c] /= sum
+		}
+		total += maxLog + math.Log(sum)
+	}
+	return total, nil
+}
+
+// logGaussian evaluates the log density of a multivariate normal given the Cholesky factor of its covariance
+func logGaussian(x, mean []float64, chol [][]float64, logDet float64) float64 {
+	dim := len(x)
+
+	// Solve L z = (x - mean) by forward substitution; the Mahalanobis distance is |z|^2
+	z := make([]float64, dim)
+	mahalanobis := 0.0
+	for a := 0; a < dim; a++ {
+		v := x[a] - mean[a]
+		for b := 0; b < a; b++ {
+			v -= chol[a][b] * z[b]
+		}
+		z[a] = v / chol[a][a]
+		mahalanobis += z[a] * z[a]
+	}
+
+	return -0.5 * (float64(dim)*math.Log(2*math.Pi) + logDet + mahalanobis)
+}
+
+// cholesky returns the lower-triangular factor L with L Lᵀ equal to the symmetric matrix m
+func cholesky(m [][]float64) ([][]float64, error) {
+	dim := len(m)
+	l := make([][]float64, dim)
+	for a := range l {
+		l[a] = make([]float64, dim)
+	}
+
+	for a := 0; a < dim; a++ {
+		for b := 0; b <= a; b++ {
+			sum := m[a][b]
+			for c := 0; c < b; c++ {
+				sum -= l[a][c] * l[b][c]
+			}
+			if a == b {
+				if sum <= 0 {
+					return nil, fmt.Errorf("covariance matrix is not positive definite")
+				}
+				l[a][a] = math.Sqrt(sum)
+			} else {
+				l[a][b] = sum / l[b][b]
+			}
+		}
+	}
+	return l, nil
+}
+
+// columnVariances returns the population variance of every column
+func columnVariances(points [][]float64) []float64 {
+	dim := len(points[0])
+	mean := make([]float64, dim)
+	for _, p := range points {
+		for d := range p {
+			mean[d] += p[d]
+		}
+	}
+	for d := range mean {
+		mean[d] /= float64(len(points))
+	}
+
+	variances := make([]float64, dim)
+	for _, p := range points {
+		for d := range p {
+			diff := p[d] - mean[d]
+			variances[d] += diff * diff
+		}
+	}
+	for d := range variances {
+		variances[d] /= float64(len(points))
+	}
+	return variances
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Linkage selects how the distance between two clusters is derived from their members
+type Linkage string
+
+const (
+	SingleLinkage   Linkage = "single"
+	CompleteLinkage Linkage = "complete"
+	AverageLinkage  Linkage = "average"
+)
+
+// DendrogramNode is one node of the merge tree. Leaves (Left == Right == nil) hold the
+// index of an input point; internal nodes record the distance at which their children merged.
+// IDs follow the usual convention: leaves are 0..n-1 and merges are numbered n, n+1, ... in order.
+type DendrogramNode struct {
+	ID       int             `json:"id"`
+	Left     *DendrogramNode `json:"left,omitempty"`
+	Right    *DendrogramNode `json:"right,omitempty"`
+	Distance float64         `json:"distance"`
+	Size     int             `json:"size"`
+	Point    int             `json:"point"`
+}
+
+// IsLeaf reports whether the node is a single input point
+func (n *DendrogramNode) IsLeaf() bool {
+	return n.Left == nil && n.Right == nil
+}
+
+// Agglomerate builds the merge tree bottom-up, repeatedly joining the two closest clusters.
+// Cluster distances are maintained with the Lance-Williams update, so each merge only
+// touches one row of the distance matrix.
+func Agglomerate(points [][]float64, linkage Linkage) (*DendrogramNode, error) {
+	n := len(points)
+	if n == 0 {
+		return nil, fmt.Errorf("no points to cluster")
+	}
+	switch linkage {
+	case SingleLinkage, CompleteLinkage, AverageLinkage:
+	default:
+		return nil, fmt.Errorf("unknown linkage %q (use single, complete or average)", linkage)
+	}
+
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = make([]float64, n)
+		for j := 0; j < i; j++ {
+			dist[i][j] = EuclideanDistance(points[i], points[j])
+			dist[j][i] = dist[i][j]
+		}
+	}
+
+	// active[i] holds the cluster currently stored in row i of the matrix, or nil once merged away
+	active := make([]*DendrogramNode, n)
+	for i := range active {
+		active[i] = &DendrogramNode{ID: i, Size: 1, Point: i}
+	}
+
+	nextID := n
+	for remaining := n; remaining > 1; remaining-- {
+		a, b := -1, -1
+		best := math.Inf(1)
+		for i := 0; i < n; i++ {
+			if active[i] == nil {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if active[j] != nil && dist[i][j] < best {
+					best = dist[i][j]
+					a, b = i, j
+				}
+			}
+		}
+
+		merged := &DendrogramNode{
+			ID:       nextID,
+			Left:     active[a],
+			Right:    active[b],
+			Distance: best,
+			Size:     active[a].Size + active[b].Size,
+			Point:    -1,
+		}
+		nextID++
+
+		for k := 0; k < n; k++ {
+			if active[k] == nil || k == a || k == b {
+				continue
+			}
+			var d float64
+			switch linkage {
+			case SingleLinkage:
+				d = math.Min(dist[a][k], dist[b][k])
+			case CompleteLinkage:
+				d = math.Max(dist[a][k], dist[b][k])
+			case AverageLinkage:
+				sa, sb := float64(active[a].Size), float64(active[b].Size)
+				d = (sa*dist[a][k] + sb*dist[b][k]) / (sa + sb)
+			}
+			dist[a][k] = d
+			dist[k][a] = d
+		}
+
+		active[a] = merged
+		active[b] = nil
+	}
+
+	for _, node := range active {
+		if node != nil {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("clustering produced no root")
+}
+
+// CutAtK undoes the last k-1 merges and labels each point with its cluster (0..k-1)
+func CutAtK(root *DendrogramNode, numPoints, k int) []int {
+	if k < 1 {
+		k = 1
+	}
+	clusters := []*DendrogramNode{root}
+	for len(clusters) < k {
+		// The most recent merge has the highest ID, so split that one next
+		sort.Slice(clusters, func(i, j int) bool { return clusters[i].ID > clusters[j].ID })
+		if clusters[0].IsLeaf() {
+			break
+		}
+		top := clusters[0]
+		clusters = append(clusters[1:], top.Left, top.Right)
+	}
+	return labelClusters(clusters, numPoints)
+}
+
+// CutAtDistance splits every merge made above the given distance and labels the resulting clusters
+func CutAtDistance(root *DendrogramNode, numPoints int, distance float64) []int {
+	var clusters []*DendrogramNode
+	var walk func(node *DendrogramNode)
+	walk = func(node *DendrogramNode) {
+		if node.IsLeaf() || node.Distance <= distance {
+			clusters = append(clusters, node)
+			return
+		}
+		walk(node.Left)
+		walk(node.Right)
+	}
+	walk(root)
+	return labelClusters(clusters, numPoints)
+}
+
+// labelClusters assigns cluster numbers in order of each cluster's lowest point index,
+// so labels are stable regardless of how the cut was made
+func labelClusters(clusters []*DendrogramNode, numPoints int) []int {
+	members := make([][]int, len(clusters))
+	for i, cluster := range clusters {
+		members[i] = leafPoints(cluster)
+		sort.Ints(members[i])
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i][0] < members[j][0] })
+
+	labels := make([]int, numPoints)
+	for label, points := range members {
+		for _, p := range points {
+			labels[p] = label
+		}
+	}
+	return labels
+}
+
+// leafPoints returns the indices of all input points under a node
+func leafPoints(node *DendrogramNode) []int {
+	if node.IsLeaf() {
+		return []int{node.Point}
+	}
+	return append(leafPoints(node.Left), leafPoints(node.Right)...)
+}
+
+// ExportDendrogram writes the merge tree as JSON, or as Graphviz DOT when the file ends in .dot
+func ExportDendrogram(root *DendrogramNode, filename string) error {
+	var data []byte
+	if strings.EqualFold(filepath.Ext(filename), ".dot") {
+		data = []byte(DendrogramDOT(root))
+	} else {
+		var err error
+		data, err = json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding dendrogram: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("error writing dendrogram: %v", err)
+	}
+	return nil
+}
+
+// DendrogramDOT renders the merge tree in Graphviz DOT format
+func DendrogramDOT(root *DendrogramNode) string {
+	var sb strings.Builder
+	sb.WriteString("digraph dendrogram {\n")
+	sb.WriteString("  node [shape=box];\n")
+
+	var walk func(node *DendrogramNode)
+	walk = func(node *DendrogramNode) {
+		if node.IsLeaf() {
+			fmt.Fprintf(&sb, "  n%d [label=\"point %d\"];\n", node.ID, node.Point)
+			return
+		}
+		fmt.Fprintf(&sb, "  n%d [label=\"d=%.4f\\nsize=%d\", shape=ellipse];\n", node.ID, node.Distance, node.Size)
+		fmt.Fprintf(&sb, "  n%d -> n%d;\n", node.ID, node.Left.ID)
+		fmt.Fprintf(&sb, "  n%d -> n%d;\n", node.ID, node.Right.ID)
+		walk(node.Left)
+		walk(node.Right)
+	}
+	walk(root)
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
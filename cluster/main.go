@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadCsv loads a CSV file and keeps only the columns where every value is numeric.
+// It returns the numeric column names, the points (one per row) and the raw records
+// so that results can be written back next to the original data.
+func LoadCsv(filename string) ([]string, [][]float64, [][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error reading file: %v", err)
+	}
+
+	if len(records) < 2 {
+		return nil, nil, nil, fmt.Errorf("insufficient data in CSV file")
+	}
+
+	header := records[0]
+	numericCols := detectNumericColumns(records[1:])
+	if len(numericCols) == 0 {
+		return nil, nil, nil, fmt.Errorf("no numeric columns found in %s", filename)
+	}
+
+	var features []string
+	for _, col := range numericCols {
+		features = append(features, header[col])
+	}
+
+	points := make([][]float64, len(records)-1)
+	for i, row := range records[1:] {
+		points[i] = make([]float64, len(numericCols))
+		for j, col := range numericCols {
+			points[i][j], _ = strconv.ParseFloat(strings.TrimSpace(row[col]), 64)
+		}
+	}
+
+	return features, points, records, nil
+}
+
+// detectNumericColumns returns the indices of the columns in which every value parses as a float
+func detectNumericColumns(data [][]string) []int {
+	var cols []int
+	for col := 0; col < len(data[0]); col++ {
+		isNumeric := true
+		for _, row := range data {
+			if col >= len(row) {
+				isNumeric = false
+				break
+			}
+			if _, err := strconv.ParseFloat(strings.TrimSpace(row[col]), 64); err != nil {
+				isNumeric = false
+				break
+			}
+		}
+		if isNumeric {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// EuclideanDistance returns the straight-line distance between two points
+func EuclideanDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// WriteAssignments writes the original records with the cluster labels appended as a "Cluster" column.
+// When memberships is non-nil, one probability column per cluster ("P_Cluster0", ...) is appended as well.
+func WriteAssignments(outputFile string, records [][]string, labels []int, memberships [][]float64) error {
+	outFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	defer writer.Flush()
+
+	newHeader := append(append([]string{}, records[0]...), "Cluster")
+	if len(memberships) > 0 {
+		for c := range memberships[0] {
+			newHeader = append(newHeader, fmt.Sprintf("P_Cluster%d", c))
+		}
+	}
+	writer.Write(newHeader)
+
+	for i, row := range records[1:] {
+		newRow := append(append([]string{}, row...), strconv.Itoa(labels[i]))
+		if memberships != nil {
+			for _, p := range memberships[i] {
+				newRow = append(newRow, strconv.FormatFloat(p, 'f', 6, 64))
+			}
+		}
+		writer.Write(newRow)
+	}
+	return nil
+}
+
+// RunHierarchical clusters the input file bottom-up, cuts the dendrogram and writes the results
+func RunHierarchical(inputFile, linkage string, k int, cutDistance float64, outputFile, dendrogramFile string) error {
+	features, points, records, err := LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Clustering on columns:", features)
+
+	root, err := Agglomerate(points, Linkage(linkage))
+	if err != nil {
+		return err
+	}
+
+	var labels []int
+	if cutDistance > 0 {
+		labels = CutAtDistance(root, len(points), cutDistance)
+	} else {
+		labels = CutAtK(root, len(points), k)
+	}
+	PrintScores(ScoreClustering(points, labels))
+
+	if outputFile != "" {
+		if err := WriteAssignments(outputFile, records, labels, nil); err != nil {
+			return err
+		}
+		fmt.Println("Cluster assignments saved to", outputFile)
+	}
+
+	if dendrogramFile != "" {
+		if err := ExportDendrogram(root, dendrogramFile); err != nil {
+			return err
+		}
+		fmt.Println("Dendrogram saved to", dendrogramFile)
+	}
+	return nil
+}
+
+// RunGMM fits a Gaussian mixture to the input file and writes hard and soft cluster memberships
+func RunGMM(inputFile string, components int, covType string, maxIter int, tol float64, seed int64, outputFile string) error {
+	features, points, records, err := LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Clustering on columns:", features)
+
+	gmm, err := FitGMM(points, components, CovarianceType(covType), maxIter, tol, seed)
+	if err != nil {
+		return err
+	}
+	for iter, ll := range gmm.History {
+		fmt.Printf("Iteration %d: log-likelihood %.6f\n", iter+1, ll)
+	}
+	if gmm.Converged {
+		fmt.Printf("Converged after %d iterations, log-likelihood %.6f\n", gmm.Iterations, gmm.LogLikelihood)
+	} else {
+		fmt.Printf("Stopped after %d iterations without converging, log-likelihood %.6f\n", gmm.Iterations, gmm.LogLikelihood)
+	}
+
+	memberships, labels, err := gmm.Predict(points)
+	if err != nil {
+		return err
+	}
+	PrintScores(ScoreClustering(points, labels))
+
+	if err := WriteAssignments(outputFile, records, labels, memberships); err != nil {
+		return err
+	}
+	fmt.Println("Cluster assignments saved to", outputFile)
+	return nil
+}
+
+// RunSweep clusters the input once per k in the range and reports inertia, silhouette and
+// Davies-Bouldin for each, so the cluster count can be picked from the elbow or the best score
+func RunSweep(inputFile, method, kRange, linkage, covType string, maxIter int, tol float64, seed int64, outputFile string) error {
+	lo, hi, err := ParseKRange(kRange)
+	if err != nil {
+		return err
+	}
+
+	features, points, _, err := LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Clustering on columns:", features)
+	if hi > len(points) {
+		hi = len(points)
+	}
+
+	// The dendrogram does not depend on k, so hierarchical clustering is built once and cut repeatedly
+	var root *DendrogramNode
+	if method == "hclust" {
+		root, err = Agglomerate(points, Linkage(linkage))
+		if err != nil {
+			return err
+		}
+	}
+
+	var results []SweepResult
+	for k := lo; k <= hi; k++ {
+		var labels []int
+		switch method {
+		case "hclust":
+			labels = CutAtK(root, len(points), k)
+		case "gmm":
+			gmm, err := FitGMM(points, k, CovarianceType(covType), maxIter, tol, seed)
+			if err != nil {
+				return fmt.Errorf("k=%d: %v", k, err)
+			}
+			_, labels, err = gmm.Predict(points)
+			if err != nil {
+				return fmt.Errorf("k=%d: %v", k, err)
+			}
+		default:
+			return fmt.Errorf("unknown clustering method %q", method)
+		}
+
+		result := ScoreClustering(points, labels)
+		result.K = k
+		results = append(results, result)
+	}
+
+	return WriteSweep(results, outputFile)
+}
+
+func main() {
+	// Define CLI flags
+	command := flag.String("c", "", "Command: hclust or gmm")
+	inputFile := flag.String("i", "", "Input CSV file")
+	outputFile := flag.String("o", "", "Output CSV file with a Cluster column")
+	linkage := flag.String("linkage", "average", "Linkage: single, complete or average")
+	k := flag.Int("k", 2, "Number of clusters to cut the dendrogram into")
+	cutDistance := flag.Float64("cut-distance", 0, "Cut the dendrogram at this merge distance instead of at k clusters")
+	dendrogramFile := flag.String("dendrogram", "", "Export the merge tree (.json or .dot)")
+	components := flag.Int("components", 2, "Number of mixture components (gmm)")
+	covType := flag.String("covariance", "diag", "Covariance type: diag or full (gmm)")
+	maxIter := flag.Int("max-iter", 100, "Maximum EM iterations (gmm)")
+	tol := flag.Float64("tol", 1e-4, "Stop when the log-likelihood improves by less than this (gmm)")
+	seed := flag.Int64("seed", 1, "Random seed for initialisation (gmm)")
+	sweepK := flag.String("sweep-k", "", "Score every k in a range such as 2..15 instead of clustering once")
+
+	// Parse flags
+	flag.Parse()
+
+	// A k sweep replaces the normal run for either method
+	if *sweepK != "" {
+		if *inputFile == "" || (*command != "hclust" && *command != "gmm") {
+			fmt.Println("Usage: cluster -c <hclust|gmm> -i <data.csv> -sweep-k 2..15 [-o <sweep.csv>]")
+			return
+		}
+		err := RunSweep(*inputFile, *command, *sweepK, *linkage, *covType, *maxIter, *tol, *seed, *outputFile)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+		return
+	}
+
+	// Execute command
+	switch *command {
+	case "hclust":
+		if *inputFile == "" || (*outputFile == "" && *dendrogramFile == "") {
+			fmt.Println("Usage: cluster -c hclust -i <data.csv> [-linkage average] [-k 3 | -cut-distance 1.5] -o <clusters.csv> [-dendrogram <tree.json|tree.dot>]")
+			return
+		}
+		err := RunHierarchical(*inputFile, *linkage, *k, *cutDistance, *outputFile, *dendrogramFile)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	case "gmm":
+		if *inputFile == "" || *outputFile == "" {
+			fmt.Println("Usage: cluster -c gmm -i <data.csv> [-components 3] [-covariance diag|full] -o <clusters.csv>")
+			return
+		}
+		err := RunGMM(*inputFile, *components, *covType, *maxIter, *tol, *seed, *outputFile)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	default:
+		fmt.Println("Invalid command. Use 'hclust' or 'gmm'.")
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Inertia returns the within-cluster sum of squared distances to each cluster's centroid
+func Inertia(points [][]float64, labels []int) float64 {
+	centroids := clusterCentroids(points, labels)
+	total := 0.0
+	for i, p := range points {
+		d := EuclideanDistance(p, centroids[labels[i]])
+		total += d * d
+	}
+	return total
+}
+
+// Silhouette returns the mean silhouette coefficient over all points. For each point,
+// a is the mean distance to its own cluster and b the mean distance to the nearest other
+// cluster; the coefficient is (b - a) / max(a, b), and 0 for points alone in their cluster.
+func Silhouette(points [][]float64, labels []int) float64 {
+	numClusters := countClusters(labels)
+	if numClusters < 2 {
+		return 0
+	}
+
+	sizes := make([]int, numClusters)
+	for _, l := range labels {
+		sizes[l]++
+	}
+
+	total := 0.0
+	for i, p := range points {
+		if sizes[labels[i]] == 1 {
+			continue
+		}
+
+		sums := make([]float64, numClusters)
+		for j, q := range points {
+			if i != j {
+				sums[labels[j]] += EuclideanDistance(p, q)
+			}
+		}
+
+		a := sums[labels[i]] / float64(sizes[labels[i]]-1)
+		b := math.Inf(1)
+		for c := range sums {
+			if c != labels[i] && sizes[c] > 0 {
+				b = math.Min(b, sums[c]/float64(sizes[c]))
+			}
+		}
+		total += (b - a) / math.Max(a, b)
+	}
+	return total / float64(len(points))
+}
+
+// DaviesBouldin returns the Davies-Bouldin index: the mean, over clusters, of the worst
+// ratio of combined scatter to centroid separation. Lower values mean better separated clusters.
+func DaviesBouldin(points [][]float64, labels []int) float64 {
+	numClusters := countClusters(labels)
+	if numClusters < 2 {
+		return 0
+	}
+
+	centroids := clusterCentroids(points, labels)
+	scatter := make([]float64, numClusters)
+	sizes := make([]int, numClusters)
+	for i, p := range points {
+		scatter[labels[i]] += EuclideanDistance(p, centroids[labels[i]])
+		sizes[labels[i]]++
+	}
+	for c := range scatter {
+		if sizes[c] > 0 {
+			scatter[c] /= float64(sizes[c])
+		}
+	}
+
+	total := 0.0
+	for a := 0; a < numClusters; a++ {
+		worst := 0.0
+		for b := 0; b < numClusters; b++ {
+			if a == b {
+				continue
+			}
+			separation := EuclideanDistance(centroids[a], centroids[b])
+			if separation > 0 {
+				worst = math.Max(worst, (scatter[a]+scatter[b])/separation)
+			}
+		}
+		total += worst
+	}
+	return total / float64(numClusters)
+}
+
+// clusterCentroids returns the mean point of every cluster
+func clusterCentroids(points [][]float64, labels []int) [][]float64 {
+	numClusters := countClusters(labels)
+	dim := len(points[0])
+	centroids := make([][]float64, numClusters)
+	sizes := make([]int, numClusters)
+	for c := range centroids {
+		centroids[c] = make([]float64, dim)
+	}
+
+	for i, p := range points {
+		for d := range p {
+			centroids[labels[i]][d] += p[d]
+		}
+		sizes[labels[i]]++
+	}
+	for c := range centroids {
+		for d := range centroids[c] {
+			if sizes[c] > 0 {
+				centroids[c][d] /= float64(sizes[c])
+			}
+		}
+	}
+	return centroids
+}
+
+// countClusters returns one more than the highest label in use
+func countClusters(labels []int) int {
+	n := 0
+	for _, l := range labels {
+		if l+1 > n {
+			n = l + 1
+		}
+	}
+	return n
+}
+
+// ParseKRange parses a range such as "2..15" into its inclusive bounds
+func ParseKRange(value string) (int, int, error) {
+	parts := strings.Split(value, "..")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid k range %q, expected e.g. 2..15", value)
+	}
+	lo, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid k range %q: %v", value, err)
+	}
+	hi, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid k range %q: %v", value, err)
+	}
+	if lo < 1 || hi < lo {
+		return 0, 0, fmt.Errorf("invalid k range %q: bounds must satisfy 1 <= lo <= hi", value)
+	}
+	return lo, hi, nil
+}
+
+// SweepResult holds the quality scores of one clustering in a k sweep
+type SweepResult struct {
+	K             int
+	Inertia       float64
+	Silhouette    float64
+	DaviesBouldin float64
+}
+
+// ScoreClustering computes every quality measure for one labelling
+func ScoreClustering(points [][]float64, labels []int) SweepResult {
+	return SweepResult{
+		K:             countClusters(labels),
+		Inertia:       Inertia(points, labels),
+		Silhouette:    Silhouette(points, labels),
+		DaviesBouldin: DaviesBouldin(points, labels),
+	}
+}
+
+// PrintScores prints the quality measures of a single clustering
+func PrintScores(result SweepResult) {
+	fmt.Printf("Inertia: %.4f\n", result.Inertia)
+	fmt.Printf("Silhouette: %.4f\n", result.Silhouette)
+	fmt.Printf("Davies-Bouldin: %.4f\n", result.DaviesBouldin)
+}
+
+// WriteSweep prints the sweep as a table and, when outputFile is set, also saves it as CSV
+func WriteSweep(results []SweepResult, outputFile string) error {
+	fmt.Printf("%4s %14s %12s %15s\n", "k", "inertia", "silhouette", "davies_bouldin")
+	for _, r := range results {
+		fmt.Printf("%4d %14.4f %12.4f %15.4f\n", r.K, r.Inertia, r.Silhouette, r.DaviesBouldin)
+	}
+
+	if outputFile == "" {
+		return nil
+	}
+
+	outFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	defer writer.Flush()
+
+	writer.Write([]string{"k", "inertia", "silhouette", "davies_bouldin"})
+	for _, r := range results {
+		writer.Write([]string{
+			strconv.Itoa(r.K),
+			strconv.FormatFloat(r.Inertia, 'f', 6, 64),
+			strconv.FormatFloat(r.Silhouette, 'f', 6, 64),
+			strconv.FormatFloat(r.DaviesBouldin, 'f', 6, 64),
+		})
+	}
+	fmt.Println("Sweep saved to", outputFile)
+	return nil
+}
@@ -0,0 +1,76 @@
+// Package datetime detects date columns in raw CSV values and encodes their cyclical
+// components (month, day of week, hour) as sin/cos pairs for the gradient-trained models.
+package datetime
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the formats tried, in order, when detecting datetime columns
+var dateLayouts = []string{
+	"2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00", "2006-01-02 15:04",
+	"2006-01-02", "02/01/2006", "01-02-2006", "2006/01/02",
+}
+
+// cyclePeriods maps each cyclical component to its period
+var cyclePeriods = map[string]float64{
+	"month": 12,
+	"dow":   7,
+	"hour":  24,
+}
+
+// DetectLayout returns the first layout that parses every value, or "" if none does
+func DetectLayout(values []string) string {
+	for _, layout := range dateLayouts {
+		matched := true
+		for _, v := range values {
+			if _, err := time.Parse(layout, strings.TrimSpace(v)); err != nil {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return layout
+		}
+	}
+	return ""
+}
+
+// Cycles picks the cyclical components worth encoding: month and day of week always,
+// hour only when the column actually carries a time of day
+func Cycles(layout string, values []string) []string {
+	cycles := []string{"month", "dow"}
+	for _, v := range values {
+		t, _ := time.Parse(layout, strings.TrimSpace(v))
+		if t.Hour() != 0 || t.Minute() != 0 {
+			return append(cycles, "hour")
+		}
+	}
+	return cycles
+}
+
+// EncodeCyclical appends a sin/cos pair per component, so values at opposite ends of a cycle
+// (23:00 and 01:00, December and January) end up close together
+func EncodeCyclical(x []float64, cycles []string, layout, value string) []float64 {
+	t, err := time.Parse(layout, strings.TrimSpace(value))
+	for _, cycle := range cycles {
+		if err != nil {
+			x = append(x, 0, 0)
+			continue
+		}
+		var position float64
+		switch cycle {
+		case "month":
+			position = float64(t.Month() - 1)
+		case "dow":
+			position = float64(t.Weekday())
+		case "hour":
+			position = float64(t.Hour()) + float64(t.Minute())/60
+		}
+		angle := 2 * math.Pi * position / cyclePeriods[cycle]
+		x = append(x, math.Sin(angle), math.Cos(angle))
+	}
+	return x
+}
@@ -0,0 +1,33 @@
+package dtree
+
+import "fmt"
+
+// Abstention routes predictions whose most likely class falls below a confidence threshold to
+// a review label instead of a forced guess
+type Abstention struct {
+	MinConfidence float64 // 0 disables abstention
+	Label         string
+}
+
+// rejects reports whether a prediction with these class probabilities should be abstained
+// from. Models without class counts give no probabilities and are never rejected.
+func (a Abstention) rejects(probs map[string]float64) bool {
+	if a.MinConfidence <= 0 || probs == nil {
+		return false
+	}
+	for _, p := range probs {
+		if p >= a.MinConfidence {
+			return false
+		}
+	}
+	return true
+}
+
+// printAbstention reports how many predictions were routed to review
+func printAbstention(a Abstention, abstained, rows int) {
+	if a.MinConfidence <= 0 || rows == 0 {
+		return
+	}
+	fmt.Printf("Abstained on %d of %d rows (%.1f%%) with confidence below %.2f, labelled %s\n",
+		abstained, rows, 100*float64(abstained)/float64(rows), a.MinConfidence, a.Label)
+}
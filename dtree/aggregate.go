@@ -0,0 +1,44 @@
+package dtree
+
+import (
+	"fmt"
+	"strings"
+
+	"machineLearning/frame"
+)
+
+// AggregateCSV groups the rows of a CSV by the key columns and writes one row per group
+// with the requested aggregations, e.g. turning an event log into one row per user
+func (l *Loader) AggregateCSV(inputFile, groupBy, aggSpec, outputFile string) error {
+	aggs, err := frame.ParseAggs(aggSpec)
+	if err != nil {
+		return err
+	}
+
+	header, dataset, colTypes, err := l.LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+	f, err := frame.FromRecords(header, dataset, colTypes)
+	if err != nil {
+		return err
+	}
+	keys := strings.Split(groupBy, ",")
+	for i := range keys {
+		keys[i] = strings.TrimSpace(keys[i])
+	}
+
+	grouped, err := f.GroupBy(keys...)
+	if err != nil {
+		return err
+	}
+	table, err := grouped.Agg(aggs...)
+	if err != nil {
+		return err
+	}
+	if err := table.WriteCSV(outputFile); err != nil {
+		return err
+	}
+	fmt.Printf("Aggregated %d rows into %d groups in %s\n", f.Len(), table.Len(), outputFile)
+	return nil
+}
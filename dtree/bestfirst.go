@@ -0,0 +1,122 @@
+package dtree
+
+import (
+	"fmt"
+
+	"machineLearning/trees"
+)
+
+// expansion is a leaf waiting to be split during best-first growth
+type expansion struct {
+	node      *TreeNode
+	rows      [][]interface{}
+	attribute string
+	reduction float64  // impurity reduction weighted by the number of rows reaching the leaf
+	path      []string // attributes split on above the leaf
+}
+
+// BuildDecisionTreeBestFirst grows a tree leaf-wise: it always splits the leaf whose best
+// split reduces impurity the most, and stops once the tree has maxLeaves leaves or no leaf
+// can be improved. Unlike BuildDecisionTree this bounds model size directly.
+func BuildDecisionTreeBestFirst(dataset [][]interface{}, header []string, maxLeaves int) *TreeNode {
+	return buildBestFirst(dataset, header, TrainConfig{MaxLeaves: maxLeaves, Criterion: InfoGainRatio})
+}
+
+// buildBestFirst grows a tree best-first up to cfg.MaxLeaves leaves, never splitting a leaf
+// at cfg.MaxDepth
+func buildBestFirst(dataset [][]interface{}, header []string, cfg TrainConfig) *TreeNode {
+	rootCounts := CountClassOccurrences(dataset)
+	root := &TreeNode{Class: majorityClass(rootCounts), IsLeaf: true, ClassCounts: rootCounts}
+	queue := trees.NewHeap(func(a, b expansion) bool { return a.reduction > b.reduction })
+	if candidate, ok := bestExpansion(root, dataset, header, cfg, nil); ok {
+		queue.Push(candidate)
+	}
+
+	leaves := 1
+	for leaves < cfg.MaxLeaves {
+		next, ok := queue.Pop()
+		if !ok {
+			break
+		}
+
+		children := splitForExpansion(next, header)
+		next.node.Attribute = next.attribute
+		next.node.IsLeaf = false
+		next.node.Class = ""
+		next.node.Children = make(map[string]*TreeNode)
+		for key, subset := range children.subsets {
+			// An empty side of a numeric split falls back to the parent's majority class
+			classRows := subset
+			if len(subset) == 0 {
+				classRows = next.rows
+			}
+			classCounts := CountClassOccurrences(classRows)
+			child := &TreeNode{Class: majorityClass(classCounts), IsLeaf: true, ClassCounts: classCounts}
+			next.node.Children[key] = child
+			if candidate, ok := bestExpansion(child, subset, header, cfg, extendPath(next.path, next.attribute)); ok {
+				queue.Push(candidate)
+			}
+		}
+		next.node.Threshold = children.threshold
+		next.node.MissingBranch = children.missingBranch
+		next.node.Surrogates = findSurrogates(children.subsets, header, findColumn(header, next.attribute), cfg.surrogateUsable(next.path))
+		leaves += len(children.subsets) - 1
+	}
+	return root
+}
+
+// nodeSplit holds the subsets a leaf is split into, keyed like TreeNode children
+type nodeSplit struct {
+	subsets       map[string][][]interface{}
+	threshold     float64
+	missingBranch string
+}
+
+// splitForExpansion splits a leaf's rows on its chosen attribute, as BuildDecisionTree would
+func splitForExpansion(e expansion, header []string) nodeSplit {
+	attrIndex := findColumn(header, e.attribute)
+	if _, ok := e.rows[0][attrIndex].(string); ok {
+		return nodeSplit{subsets: splitDataset(e.rows, header, e.attribute, nil)}
+	}
+	split := findThreshold(e.rows, attrIndex)
+	return nodeSplit{
+		subsets: map[string][][]interface{}{
+			fmt.Sprintf("<=%.2f", split.threshold): split.left,
+			fmt.Sprintf(">%.2f", split.threshold):  split.right,
+		},
+		threshold:     split.threshold,
+		missingBranch: split.missingBranch(),
+	}
+}
+
+// bestExpansion finds the best split of a leaf reached by splitting on the attributes in path
+// and reports whether it improves impurity
+func bestExpansion(node *TreeNode, rows [][]interface{}, header []string, cfg TrainConfig, path []string) (expansion, bool) {
+	if len(rows) < 2 || len(CountClassOccurrences(rows)) < 2 {
+		return expansion{}, false
+	}
+	if (cfg.MaxDepth > 0 && len(path) >= cfg.MaxDepth) || len(rows) < cfg.MinSamplesSplit {
+		return expansion{}, false
+	}
+	idx := IndexDataset(rows)
+	attribute := bestAttribute(rows, header, idx, cfg, path)
+	if attribute == "" {
+		return expansion{}, false
+	}
+	gain := impurityDecrease(cfg.Criterion, rows, header, attribute, idx) * cfg.penalty(attribute)
+	if gain <= 0 {
+		return expansion{}, false
+	}
+	return expansion{node: node, rows: rows, attribute: attribute, reduction: gain * float64(len(rows)), path: path}, true
+}
+
+// majorityClass returns the most frequent class, breaking ties alphabetically
+func majorityClass(classCounts map[string]int) string {
+	best, bestCount := "", 0
+	for class, count := range classCounts {
+		if count > bestCount || (count == bestCount && class < best) {
+			best, bestCount = class, count
+		}
+	}
+	return best
+}
@@ -0,0 +1,94 @@
+package dtree
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheVersion changes whenever the cached layout or the conversion rules change, so stale
+// entries are never read back
+const cacheVersion = 1
+
+// cachedDataset is the on-disk form of a parsed CSV
+type cachedDataset struct {
+	Header   []string
+	ColTypes []string
+	Rows     [][]interface{}
+}
+
+func init() {
+	gob.Register(time.Time{}) // dates are stored as interface values
+}
+
+// datasetKey hashes the file's contents together with every loader option that affects
+// parsing, so a cache entry is only reused for identical input and configuration
+func datasetKey(filename string, opts LoadOptions) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", openError("error opening file", err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("error reading file: %v", err)
+	}
+	// How the file is read and where the cache lives do not change the result
+	opts.Mmap, opts.CacheDir = false, ""
+	fmt.Fprintf(h, "\x00v%d %+v", cacheVersion, opts)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadCachedCsv returns the parsed dataset from the cache, parsing and storing it on a miss
+func (l *Loader) loadCachedCsv(filename string, opts LoadOptions) ([]string, [][]interface{}, []string, error) {
+	key, err := datasetKey(filename, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	path := filepath.Join(opts.CacheDir, key+".gob")
+
+	if file, err := os.Open(path); err == nil {
+		defer file.Close()
+		var cached cachedDataset
+		if err := gob.NewDecoder(file).Decode(&cached); err == nil {
+			return cached.Header, cached.Rows, cached.ColTypes, nil
+		}
+		// A corrupt entry is simply rebuilt below
+	}
+
+	header, dataset, colTypes, err := l.parseCsv(filename, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := writeCache(path, cachedDataset{Header: header, ColTypes: colTypes, Rows: dataset}); err != nil {
+		l.warn("could not cache dataset: %v", err)
+	}
+	return header, dataset, colTypes, nil
+}
+
+// writeCache stores an entry atomically so concurrent runs never read a partial file
+func writeCache(path string, cached cachedDataset) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "dataset-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(cached); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,86 @@
+package dtree
+
+import (
+	"fmt"
+
+	"machineLearning/metrics"
+)
+
+// Comparison holds two models' reports on the same labelled rows and how their mistakes overlap
+type Comparison struct {
+	A, B      *metrics.Report
+	Agreement *metrics.Agreement
+}
+
+// CompareModels predicts every row of a labelled CSV with two models, prints both
+// classification reports, the contingency table of which model got each row right, and
+// McNemar's test of whether their accuracies really differ. The CSV is parsed once per model,
+// the way that model's training data was.
+func (l *Loader) CompareModels(inputFile, modelA, modelB, targetCol string) (*Comparison, error) {
+	treeA, err := l.LoadModel(modelA)
+	if err != nil {
+		return nil, err
+	}
+	treeB, err := l.LoadModel(modelB)
+	if err != nil {
+		return nil, err
+	}
+
+	actualA, predictedA, err := l.scoreWithModel(inputFile, treeA, targetCol)
+	if err != nil {
+		return nil, fmt.Errorf("error predicting with %s: %w", modelA, err)
+	}
+	actualB, predictedB, err := l.scoreWithModel(inputFile, treeB, targetCol)
+	if err != nil {
+		return nil, fmt.Errorf("error predicting with %s: %w", modelB, err)
+	}
+	c := &Comparison{}
+	if c.A, err = metrics.Classification(actualA, predictedA); err != nil {
+		return nil, err
+	}
+	if c.B, err = metrics.Classification(actualB, predictedB); err != nil {
+		return nil, err
+	}
+	if c.Agreement, err = metrics.CompareModelLabels(actualA, predictedA, actualB, predictedB); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("== A (%s)\n", modelA)
+	c.A.Print()
+	fmt.Printf("\n== B (%s)\n", modelB)
+	c.B.Print()
+	fmt.Println()
+	c.Agreement.Print("A", "B")
+	return c, nil
+}
+
+// scoreWithModel loads a labelled CSV with the model's own load options and predicts its
+// target column
+func (l *Loader) scoreWithModel(inputFile string, tree *TreeNode, targetCol string) ([]string, []string, error) {
+	header, dataset, _, err := l.loadCsv(inputFile, l.modelOptions(tree).withLabels(targetCol))
+	if err != nil {
+		return nil, nil, err
+	}
+	return predictTarget(header, dataset, tree, targetCol)
+}
+
+// predictTarget applies a model's derived columns to a loaded dataset and returns the target
+// column's labels alongside the model's predictions
+func predictTarget(header []string, dataset [][]interface{}, tree *TreeNode, targetCol string) ([]string, []string, error) {
+	header, dataset, err := DeriveColumns(header, dataset, tree.Derive, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	targetIndex := findColumn(header, targetCol)
+	if targetIndex == -1 {
+		return nil, nil, fmt.Errorf("target column %q not found", targetCol)
+	}
+
+	actual := make([]string, len(dataset))
+	predicted := make([]string, len(dataset))
+	for i, row := range dataset {
+		actual[i] = fmt.Sprintf("%v", row[targetIndex])
+		predicted[i] = Predict(tree, rowInstance(header, row, targetIndex))
+	}
+	return actual, predicted, nil
+}
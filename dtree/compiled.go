@@ -0,0 +1,196 @@
+package dtree
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// CompiledTree is a TreeNode tree flattened into parallel arrays indexed by node, with node 0
+// as the root. Walking it touches a few contiguous slices instead of chasing pointers and map
+// buckets, which makes repeated predictions much cheaper.
+type CompiledTree struct {
+	Features []string // feature names, indexed by Feature
+	Classes  []string // class labels, indexed by Class
+
+	Feature   []int32   // feature tested at each node, -1 for leaves
+	Numeric   []bool    // whether the node splits on a threshold
+	Threshold []float64 // split threshold of numeric nodes
+	Class     []int32   // leaf class, or the fallback class of an internal node
+	Missing   []int32   // child a missing value follows at numeric nodes, -1 for none
+
+	Surrogates        [][]Surrogate // surrogate splits of each node, best first
+	SurrogateFeatures [][]int32     // feature index of each surrogate
+
+	ChildStart []int32  // first entry of the node's children in Keys and Child
+	ChildCount []int32  // number of children
+	Keys       []string // branch value of each child (numeric nodes store "<=" then ">")
+	Child      []int32  // node index of each child
+}
+
+// Compile flattens a tree in breadth-first order so siblings are stored next to each other
+func Compile(root *TreeNode) *CompiledTree {
+	c := &CompiledTree{}
+	features := make(map[string]int32)
+	classes := make(map[string]int32)
+
+	featureIndex := func(name string) int32 {
+		if idx, ok := features[name]; ok {
+			return idx
+		}
+		features[name] = int32(len(c.Features))
+		c.Features = append(c.Features, name)
+		return features[name]
+	}
+	classIndex := func(name string) int32 {
+		if idx, ok := classes[name]; ok {
+			return idx
+		}
+		classes[name] = int32(len(c.Classes))
+		c.Classes = append(c.Classes, name)
+		return classes[name]
+	}
+
+	queue := []*TreeNode{root}
+	for head := 0; head < len(queue); head++ {
+		node := queue[head]
+		if node.IsLeaf {
+			c.appendNode(-1, false, 0, classIndex(node.Class))
+			continue
+		}
+
+		left, right := fmt.Sprintf("<=%.2f", node.Threshold), fmt.Sprintf(">%.2f", node.Threshold)
+		_, hasLeft := node.Children[left]
+		_, hasRight := node.Children[right]
+		numeric := hasLeft && hasRight && len(node.Children) == 2
+
+		c.appendNode(featureIndex(node.Attribute), numeric, node.Threshold, classIndex(FindMostCommonClass(node)))
+		if len(node.Surrogates) > 0 {
+			n := len(c.Feature) - 1
+			c.Surrogates[n] = node.Surrogates
+			c.SurrogateFeatures[n] = make([]int32, len(node.Surrogates))
+			for i, s := range node.Surrogates {
+				c.SurrogateFeatures[n][i] = featureIndex(s.Attribute)
+			}
+		}
+		keys := []string{left, right}
+		if !numeric {
+			keys = make([]string, 0, len(node.Children))
+			for key := range node.Children {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+		}
+
+		n := len(c.Feature) - 1
+		c.ChildStart[n] = int32(len(c.Keys))
+		c.ChildCount[n] = int32(len(keys))
+		for _, key := range keys {
+			if key == node.MissingBranch {
+				c.Missing[n] = int32(len(queue))
+			}
+			c.Keys = append(c.Keys, key)
+			// Children are numbered in the order they join the queue
+			c.Child = append(c.Child, int32(len(queue)))
+			queue = append(queue, node.Children[key])
+		}
+	}
+	return c
+}
+
+func (c *CompiledTree) appendNode(feature int32, numeric bool, threshold float64, class int32) {
+	c.Feature = append(c.Feature, feature)
+	c.Numeric = append(c.Numeric, numeric)
+	c.Threshold = append(c.Threshold, threshold)
+	c.Class = append(c.Class, class)
+	c.Missing = append(c.Missing, -1)
+	c.Surrogates = append(c.Surrogates, nil)
+	c.SurrogateFeatures = append(c.SurrogateFeatures, nil)
+	c.ChildStart = append(c.ChildStart, 0)
+	c.ChildCount = append(c.ChildCount, 0)
+}
+
+// Bind returns, for each of the tree's features, its column index in header (-1 if absent),
+// so rows can be predicted with PredictRow without building a map per row
+func (c *CompiledTree) Bind(header []string) []int {
+	columns := make([]int, len(c.Features))
+	for i, name := range c.Features {
+		columns[i] = findColumn(header, name)
+	}
+	return columns
+}
+
+// PredictRow predicts one row given the column indexes returned by Bind. It follows the same
+// rules as Predict: a missing feature gives "Unknown" and an unseen value the majority class.
+func (c *CompiledTree) PredictRow(row []string, columns []int) string {
+	node := int32(0)
+	for c.Feature[node] >= 0 {
+		col := columns[c.Feature[node]]
+		absent := col < 0 || col >= len(row)
+		value := ""
+		if !absent {
+			value = row[col]
+		}
+
+		next := int32(-1)
+		start := c.ChildStart[node]
+		if c.Numeric[node] && !absent {
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				next = c.Child[start]
+				if v > c.Threshold[node] {
+					next = c.Child[start+1]
+				}
+			}
+		}
+		for i := start; !absent && next < 0 && i < start+c.ChildCount[node]; i++ {
+			if c.Keys[i] == value {
+				next = c.Child[i]
+			}
+		}
+		if next < 0 && (absent || missingValue(value)) {
+			next = c.missingChild(node, row, columns)
+		}
+		if next < 0 && absent {
+			return "Unknown"
+		}
+		if next < 0 {
+			break
+		}
+		node = next
+	}
+	return c.Classes[c.Class[node]]
+}
+
+// missingChild routes a row missing a node's feature like childFor: by the first usable
+// surrogate, then by the default branch. It returns -1 when neither applies.
+func (c *CompiledTree) missingChild(node int32, row []string, columns []int) int32 {
+	start, end := c.ChildStart[node], c.ChildStart[node]+c.ChildCount[node]
+	for i, s := range c.Surrogates[node] {
+		col := columns[c.SurrogateFeatures[node][i]]
+		if col < 0 || col >= len(row) || missingValue(row[col]) {
+			continue
+		}
+		key, ok := s.route(row[col])
+		for j := start; ok && j < end; j++ {
+			if c.Keys[j] == key {
+				return c.Child[j]
+			}
+		}
+	}
+	return c.Missing[node]
+}
+
+// Predict predicts one instance given as a column-to-value map
+func (c *CompiledTree) Predict(instance map[string]string) string {
+	row := make([]string, len(c.Features))
+	columns := make([]int, len(c.Features))
+	for i, name := range c.Features {
+		value, ok := instance[name]
+		if !ok {
+			columns[i] = -1
+			continue
+		}
+		row[i], columns[i] = value, i
+	}
+	return c.PredictRow(row, columns)
+}
@@ -0,0 +1,178 @@
+package dtree
+
+import "fmt"
+
+// Contributions decomposes the probability the tree gives its predicted class into SHAP
+// values, computed exactly with path-dependent TreeSHAP (Lundberg et al., 2018). The bias is
+// the expected probability of the class over the training rows, and each feature's
+// contribution is its Shapley value when features left out of a coalition are averaged over
+// the children of the splits on them, weighted by the training rows each child received. The
+// bias and contributions add up to the probability of the class for the instance.
+//
+// The branch the instance takes at each split is the one prediction takes, including
+// surrogate and default-branch routing of missing values. Where no branch matches, such as an
+// unseen category, the node counts as a leaf predicting its subtree's expected probability.
+func Contributions(root *TreeNode, instance map[string]string) (string, float64, map[string]float64, error) {
+	if len(root.Members) > 0 {
+		return "", 0, nil, fmt.Errorf("contributions are not available for ensemble models")
+	}
+	if root.ClassCounts == nil {
+		return "", 0, nil, fmt.Errorf("model has no class counts; retrain it to compute contributions")
+	}
+
+	class := Predict(root, instance)
+	s := &shapState{class: class, instance: instance, phi: make(map[string]float64)}
+	s.recurse(root, nil, 1, 1, "")
+	return class, expectedShare(root, class), s.phi, nil
+}
+
+// pathElement is one feature on the path TreeSHAP extends as it descends: the fraction of
+// coalitions without the feature (zero) and with it (one) that reach this point, and the
+// permutation weight of coalitions of this size
+type pathElement struct {
+	feature   string
+	zero, one float64
+	weight    float64
+}
+
+// shapState accumulates the SHAP values of one instance for one class
+type shapState struct {
+	class    string
+	instance map[string]string
+	phi      map[string]float64
+}
+
+// recurse walks every branch of the tree, extending the path with the fractions of
+// coalitions that follow each child, and credits each feature on the path at the leaves
+func (s *shapState) recurse(node *TreeNode, path []pathElement, zero, one float64, feature string) {
+	path = extendShapPath(path, zero, one, feature)
+
+	hot := childFor(node, s.instance)
+	if node.IsLeaf || hot == nil || nodeCover(node) == 0 {
+		value := classShare(node, s.class)
+		if !node.IsLeaf {
+			value = expectedShare(node, s.class)
+		}
+		for i := 1; i < len(path); i++ {
+			w := unwoundShapSum(path, i)
+			s.phi[path[i].feature] += w * (path[i].one - path[i].zero) * value
+		}
+		return
+	}
+
+	// A feature split on again further down is unwound so each feature appears once
+	inZero, inOne := 1.0, 1.0
+	for k := 1; k < len(path); k++ {
+		if path[k].feature == node.Attribute {
+			inZero, inOne = path[k].zero, path[k].one
+			path = unwindShapPath(path, k)
+			break
+		}
+	}
+
+	cover := nodeCover(node)
+	for _, child := range node.Children {
+		share := nodeCover(child) / cover
+		isHot := 0.0
+		if child == hot {
+			isHot = 1
+		}
+		if share == 0 && isHot == 0 {
+			continue // no coalition reaches this child
+		}
+		s.recurse(child, path, inZero*share, inOne*isHot, node.Attribute)
+	}
+}
+
+// extendShapPath returns a copy of path with one more feature, growing the permutation weights
+// of every coalition size
+func extendShapPath(path []pathElement, zero, one float64, feature string) []pathElement {
+	l := len(path)
+	extended := make([]pathElement, l+1)
+	copy(extended, path)
+	extended[l] = pathElement{feature: feature, zero: zero, one: one}
+	if l == 0 {
+		extended[l].weight = 1
+	}
+	for i := l - 1; i >= 0; i-- {
+		extended[i+1].weight += one * extended[i].weight * float64(i+1) / float64(l+1)
+		extended[i].weight = zero * extended[i].weight * float64(l-i) / float64(l+1)
+	}
+	return extended
+}
+
+// unwindShapPath returns a copy of path with element i removed, undoing its extension
+func unwindShapPath(path []pathElement, i int) []pathElement {
+	depth := len(path) - 1
+	one, zero := path[i].one, path[i].zero
+	unwound := make([]pathElement, depth+1)
+	copy(unwound, path)
+
+	next := unwound[depth].weight
+	for j := depth - 1; j >= 0; j-- {
+		if one != 0 {
+			tmp := unwound[j].weight
+			unwound[j].weight = next * float64(depth+1) / (float64(j+1) * one)
+			next = tmp - unwound[j].weight*zero*float64(depth-j)/float64(depth+1)
+		} else {
+			unwound[j].weight = unwound[j].weight * float64(depth+1) / (zero * float64(depth-j))
+		}
+	}
+	for j := i; j < depth; j++ {
+		unwound[j].feature, unwound[j].zero, unwound[j].one = unwound[j+1].feature, unwound[j+1].zero, unwound[j+1].one
+	}
+	return unwound[:depth]
+}
+
+// unwoundShapSum is the total permutation weight path would have with element i removed
+func unwoundShapSum(path []pathElement, i int) float64 {
+	depth := len(path) - 1
+	one, zero := path[i].one, path[i].zero
+	next := path[depth].weight
+	total := 0.0
+	for j := depth - 1; j >= 0; j-- {
+		if one != 0 {
+			tmp := next * float64(depth+1) / (float64(j+1) * one)
+			total += tmp
+			next = path[j].weight - tmp*zero*float64(depth-j)/float64(depth+1)
+		} else {
+			total += path[j].weight / zero * float64(depth+1) / float64(depth-j)
+		}
+	}
+	return total
+}
+
+// expectedShare is the probability of class averaged over the subtree's leaves, each child
+// weighted by its share of the node's training rows
+func expectedShare(node *TreeNode, class string) float64 {
+	cover := nodeCover(node)
+	if node.IsLeaf || cover == 0 {
+		return classShare(node, class)
+	}
+	expected := 0.0
+	for _, child := range node.Children {
+		expected += nodeCover(child) / cover * expectedShare(child, class)
+	}
+	return expected
+}
+
+// nodeCover is the number of training rows that reached a node
+func nodeCover(node *TreeNode) float64 {
+	total := 0
+	for _, count := range node.ClassCounts {
+		total += count
+	}
+	return float64(total)
+}
+
+// classShare returns the fraction of the node's training rows that belong to class
+func classShare(node *TreeNode, class string) float64 {
+	total := 0
+	for _, count := range node.ClassCounts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(node.ClassCounts[class]) / float64(total)
+}
@@ -0,0 +1,114 @@
+package dtree
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CostMatrix holds the cost of each misclassification, indexed [actual][predicted]. Pairs it
+// leaves out cost 0 when the classes match and 1 otherwise.
+type CostMatrix map[string]map[string]float64
+
+// LoadCostMatrix reads a cost matrix from a CSV whose header row names the predicted classes
+// after a leading label cell, and whose other rows each give an actual class followed by the
+// cost of every prediction, e.g.
+//
+//	actual,yes,no
+//	yes,0,5
+//	no,1,0
+func LoadCostMatrix(filename string) (CostMatrix, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, openError("error opening cost matrix", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, withExitCode(ExitParse, fmt.Errorf("error reading cost matrix %s: %v", filename, err))
+	}
+	if len(records) < 2 || len(records[0]) < 2 {
+		return nil, withExitCode(ExitParse, fmt.Errorf("cost matrix %s needs a header of predicted classes and a row per actual class", filename))
+	}
+
+	predicted := records[0][1:]
+	costs := make(CostMatrix)
+	for _, record := range records[1:] {
+		actual := strings.TrimSpace(record[0])
+		if len(record) != len(predicted)+1 {
+			return nil, withExitCode(ExitParse, fmt.Errorf("cost matrix row %q has %d costs, want %d", actual, len(record)-1, len(predicted)))
+		}
+		costs[actual] = make(map[string]float64)
+		for i, cell := range record[1:] {
+			cost, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+			if err != nil || cost < 0 {
+				return nil, withExitCode(ExitParse, fmt.Errorf("cost matrix row %q: invalid cost %q", actual, cell))
+			}
+			costs[actual][strings.TrimSpace(predicted[i])] = cost
+		}
+	}
+	return costs, nil
+}
+
+// Cost returns the cost of predicting a class when the row is actually another
+func (c CostMatrix) Cost(actual, predicted string) float64 {
+	if cost, ok := c[actual][predicted]; ok {
+		return cost
+	}
+	if actual == predicted {
+		return 0
+	}
+	return 1
+}
+
+// ExpectedCost returns the cost of predicting a class, averaged over the class probabilities
+func (c CostMatrix) ExpectedCost(probs map[string]float64, predicted string) float64 {
+	expected := 0.0
+	for actual, p := range probs {
+		expected += p * c.Cost(actual, predicted)
+	}
+	return expected
+}
+
+// Decide returns the class with the lowest expected cost under the class probabilities,
+// breaking ties alphabetically. Any class the matrix or the probabilities name may be chosen.
+func (c CostMatrix) Decide(probs map[string]float64) string {
+	candidates := make(map[string]bool)
+	for class := range probs {
+		candidates[class] = true
+	}
+	for _, row := range c {
+		for class := range row {
+			candidates[class] = true
+		}
+	}
+	classes := make([]string, 0, len(candidates))
+	for class := range candidates {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	best, bestCost := "", 0.0
+	for _, class := range classes {
+		if cost := c.ExpectedCost(probs, class); best == "" || cost < bestCost {
+			best, bestCost = class, cost
+		}
+	}
+	return best
+}
+
+// decideByCost returns the class of lowest expected cost under the class probabilities of the
+// instance's leaf, and that cost. Leaves without class counts keep the fallback prediction
+// with an expected cost of 0.
+func decideByCost(tree *TreeNode, instance map[string]string, costs CostMatrix, fallback string) (string, float64) {
+	probs := Probabilities(tree, instance)
+	if probs == nil {
+		return fallback, 0
+	}
+	class := costs.Decide(probs)
+	return class, costs.ExpectedCost(probs, class)
+}
@@ -0,0 +1,175 @@
+package dtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Change is one feature edit suggested by a counterfactual
+type Change struct {
+	Feature string `json:"feature"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+// pathCondition constrains one feature along a root-to-leaf path: either an exact category
+// or a numeric interval (Lo, Hi]
+type pathCondition struct {
+	category string
+	numeric  bool
+	lo, hi   float64
+}
+
+// Counterfactual searches the tree for the smallest set of feature changes that makes the
+// instance predict the desired class. Every leaf of that class is a candidate: the changes
+// needed to satisfy its path are counted, and the leaf needing the fewest (then the smallest
+// numeric moves) wins.
+func Counterfactual(root *TreeNode, instance map[string]string, desired string) ([]Change, error) {
+	if len(root.Members) > 0 {
+		return nil, fmt.Errorf("counterfactuals are not available for ensemble models")
+	}
+	var best []Change
+	bestCost := math.Inf(1)
+	found := false
+
+	var walk func(node *TreeNode, conditions map[string]pathCondition)
+	walk = func(node *TreeNode, conditions map[string]pathCondition) {
+		if node.IsLeaf {
+			if node.Class != desired {
+				return
+			}
+			changes, cost := changesFor(instance, conditions)
+			if !found || cost < bestCost {
+				best, bestCost, found = changes, cost, true
+			}
+			return
+		}
+
+		left := fmt.Sprintf("<=%.2f", node.Threshold)
+		right := fmt.Sprintf(">%.2f", node.Threshold)
+		for key, child := range node.Children {
+			next := make(map[string]pathCondition, len(conditions)+1)
+			for k, v := range conditions {
+				next[k] = v
+			}
+
+			cond, seen := next[node.Attribute]
+			if !seen {
+				cond = pathCondition{lo: math.Inf(-1), hi: math.Inf(1)}
+			}
+			switch {
+			case key == left && len(node.Children) == 2:
+				cond.numeric = true
+				cond.hi = math.Min(cond.hi, node.Threshold)
+			case key == right && len(node.Children) == 2:
+				cond.numeric = true
+				cond.lo = math.Max(cond.lo, node.Threshold)
+			default:
+				cond.category = key
+			}
+			if cond.numeric && cond.lo >= cond.hi {
+				continue // contradictory path, no value can reach it
+			}
+			next[node.Attribute] = cond
+			walk(child, next)
+		}
+	}
+	walk(root, map[string]pathCondition{})
+
+	if !found {
+		return nil, fmt.Errorf("no leaf of the tree predicts %q", desired)
+	}
+	return best, nil
+}
+
+// changesFor lists the edits that make the instance satisfy every condition of a path. The
+// cost is the number of edits, with numeric distances as a small tie-breaker.
+func changesFor(instance map[string]string, conditions map[string]pathCondition) ([]Change, float64) {
+	features := make([]string, 0, len(conditions))
+	for feature := range conditions {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	var changes []Change
+	cost := 0.0
+	for _, feature := range features {
+		cond := conditions[feature]
+		current := instance[feature]
+		if !cond.numeric {
+			if current != cond.category {
+				changes = append(changes, Change{Feature: feature, From: current, To: cond.category})
+				cost++
+			}
+			continue
+		}
+
+		value, err := strconv.ParseFloat(current, 64)
+		if err == nil && value > cond.lo && value <= cond.hi {
+			continue
+		}
+		target := cond.hi
+		if math.IsInf(target, 1) || (err == nil && value <= cond.lo) {
+			// Step just above the lower bound, staying inside the interval
+			target = cond.lo + math.Min(0.01, (cond.hi-cond.lo)/2)
+		}
+		changes = append(changes, Change{Feature: feature, From: current, To: strconv.FormatFloat(target, 'f', -1, 64)})
+		cost++
+		if err == nil {
+			cost += math.Abs(target-value) * 1e-9
+		}
+	}
+	return changes, cost
+}
+
+// parseInstance reads an instance given as a JSON object of feature values
+func parseInstance(instanceJSON string) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(instanceJSON), &raw); err != nil {
+		return nil, fmt.Errorf("error parsing instance JSON: %v", err)
+	}
+	instance := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if v == nil {
+			instance[k] = "" // null means missing
+			continue
+		}
+		instance[k] = fmt.Sprintf("%v", v)
+	}
+	return instance, nil
+}
+
+// CounterfactualFromModel loads a model, parses the instance from JSON and prints the
+// changes that would flip its prediction to the desired class
+func (l *Loader) CounterfactualFromModel(modelFile, instanceJSON, desired string) error {
+	tree, err := l.LoadModel(modelFile)
+	if err != nil {
+		return err
+	}
+
+	instance, err := parseInstance(instanceJSON)
+	if err != nil {
+		return err
+	}
+
+	current := Predict(tree, instance)
+	fmt.Println("Current prediction:", current)
+	if current == desired {
+		fmt.Println("The instance already predicts", desired)
+		return nil
+	}
+
+	changes, err := Counterfactual(tree, instance, desired)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		instance[c.Feature] = c.To
+		fmt.Printf("  %s: %q -> %q\n", c.Feature, c.From, c.To)
+	}
+	fmt.Println("New prediction:", Predict(tree, instance))
+	return nil
+}
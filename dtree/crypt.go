@@ -0,0 +1,98 @@
+package dtree
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// encryptedMagic starts every encrypted model file, so LoadModel can tell it from plain JSON
+const encryptedMagic = "DTENC1\n"
+
+// ModelKeyEnv names the environment variable that can hold the model key instead of a file
+const ModelKeyEnv = "DT_MODEL_KEY"
+
+// LoadModelKey reads a hex-encoded AES key (16, 24 or 32 bytes) from keyFile, or from the
+// DT_MODEL_KEY environment variable when no file is given. It returns nil if neither is set.
+func LoadModelKey(keyFile string) ([]byte, error) {
+	encoded := os.Getenv(ModelKeyEnv)
+	source := ModelKeyEnv
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading key file: %v", err)
+		}
+		encoded, source = string(data), keyFile
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding key from %s: %v", source, err)
+	}
+	if n := len(key); n != 16 && n != 24 && n != 32 {
+		return nil, fmt.Errorf("key from %s is %d bytes, expected 16, 24 or 32", source, n)
+	}
+	return key, nil
+}
+
+// encryptModel seals a model payload with AES-GCM. The output is the magic header, the
+// nonce and the ciphertext; the header is authenticated along with the payload.
+func encryptModel(key, payload []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %v", err)
+	}
+
+	out := append([]byte(encryptedMagic), nonce...)
+	return gcm.Seal(out, nonce, payload, []byte(encryptedMagic)), nil
+}
+
+// decryptModel returns the payload of a model file, decrypting it when it is encrypted.
+// Plain models are only accepted when no key is configured: otherwise anyone able to replace
+// a model file could swap in one that was never sealed with the key.
+func decryptModel(key, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedMagic)) {
+		if key != nil {
+			return nil, fmt.Errorf("model is not encrypted but a model key is set; refusing an unauthenticated model")
+		}
+		return data, nil
+	}
+	if key == nil {
+		return nil, fmt.Errorf("model is encrypted; pass -encrypt-key-file or set %s", ModelKeyEnv)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed := data[len(encryptedMagic):]
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted model is truncated")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	payload, err := gcm.Open(nil, nonce, ciphertext, []byte(encryptedMagic))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting model: wrong key or corrupted file")
+	}
+	return payload, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
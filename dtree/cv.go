@@ -0,0 +1,241 @@
+package dtree
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+
+	"machineLearning/metrics"
+	"machineLearning/split"
+)
+
+// FoldResult holds the out-of-fold predictions and scores of one cross-validation fold
+type FoldResult struct {
+	Fold      split.Fold
+	Predicted []string
+	Accuracy  float64
+	F1Macro   float64
+
+	// Set when the folds are saved: the fold's model and its out-of-fold predictions
+	ModelFile       string
+	PredictionsFile string
+}
+
+// CrossValidate trains and scores one tree per stratified fold, predicting the target column
+// (the last column when target is empty). Each fold is grown by Train with opts on the columns
+// derive adds, like TrainModel, so the scores describe the model train would save. Folds are
+// independent, so they
+// run on a pool of workers goroutines (0 means one per CPU) and report progress as they finish.
+// With foldDir set, each fold's model and the predictions for its held-out rows are saved
+// there (see saveFold).
+func (l *Loader) CrossValidate(inputFile, target string, k, workers int, seed int64, foldDir string, opts []Option, derive []string) ([]FoldResult, error) {
+	if _, err := NewTrainConfig(opts...); err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	header, dataset, _, err := l.loadCsv(inputFile, l.opts.withLabels(target))
+	if err != nil {
+		return nil, err
+	}
+	header, dataset, err = DeriveColumns(header, dataset, derive, true)
+	if err != nil {
+		return nil, err
+	}
+	if target == "" {
+		target = header[len(header)-1]
+	}
+	header, dataset, err = targetView(header, dataset, target, []string{target})
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+
+	labels := make([]string, len(dataset))
+	for i, row := range dataset {
+		labels[i] = fmt.Sprintf("%v", row[len(row)-1])
+	}
+	folds, err := split.StratifiedKFold(labels, k, seed)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if foldDir != "" {
+		if err := os.MkdirAll(foldDir, 0o755); err != nil {
+			return nil, fmt.Errorf("error creating fold directory: %v", err)
+		}
+	}
+
+	jobs := make(chan split.Fold)
+	results := make([]FoldResult, k)
+	errs := make([]error, k)
+	var mu sync.Mutex
+	done := 0
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, k); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fold := range jobs {
+				result, err := l.runFold(header, dataset, labels, fold, foldDir, opts, derive)
+				results[fold.Index], errs[fold.Index] = result, err
+
+				mu.Lock()
+				done++
+				if err == nil {
+					fmt.Printf("Fold %d finished: accuracy %.4f (%d/%d done)\n", fold.Index+1, result.Accuracy, done, k)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for fold := range folds {
+		jobs <- fold
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, withDefaultExitCode(ExitTraining, fmt.Errorf("fold %d: %w", i+1, err))
+		}
+	}
+	return results, nil
+}
+
+// runFold trains on the fold's training rows and predicts its held-out rows, saving both to
+// foldDir when it is set
+func (l *Loader) runFold(header []string, dataset [][]interface{}, labels []string, fold split.Fold, foldDir string, opts []Option, derive []string) (FoldResult, error) {
+	train := make([][]interface{}, len(fold.Train))
+	for i, idx := range fold.Train {
+		train[i] = dataset[idx]
+	}
+	tree, err := Train(header, train, opts...)
+	if err != nil {
+		return FoldResult{Fold: fold}, err
+	}
+	tree.Derive = derive
+	l.warnSkipped(tree)
+
+	result := FoldResult{Fold: fold, Predicted: make([]string, len(fold.Test))}
+	actual := make([]string, len(fold.Test))
+	for i, idx := range fold.Test {
+		actual[i] = labels[idx]
+		result.Predicted[i] = Predict(tree, rowInstance(header, dataset[idx], len(header)-1))
+	}
+
+	report, err := metrics.Classification(actual, result.Predicted)
+	if err != nil {
+		return result, err
+	}
+	result.Accuracy = report.Metrics["accuracy"]
+	result.F1Macro = report.Metrics["f1_macro"]
+	if foldDir != "" {
+		err = l.saveFold(tree, header, dataset, labels, &result, foldDir)
+	}
+	return result, err
+}
+
+// saveFold writes a fold's model as fold-<n>.json and its out-of-fold predictions as
+// fold-<n>-predictions.csv. Each prediction row holds the row's position in the input
+// (counting data rows from 1), its values, the prediction and a P_<class> probability per
+// class, ready for stacking or error analysis.
+func (l *Loader) saveFold(tree *TreeNode, header []string, dataset [][]interface{}, labels []string, result *FoldResult, foldDir string) error {
+	n := result.Fold.Index + 1
+	recordLoadOptions(tree, l.opts)
+	result.ModelFile = filepath.Join(foldDir, fmt.Sprintf("fold-%d.json", n))
+	if err := l.SaveModel(tree, result.ModelFile); err != nil {
+		return err
+	}
+
+	result.PredictionsFile = filepath.Join(foldDir, fmt.Sprintf("fold-%d-predictions.csv", n))
+	outFile, err := os.Create(result.PredictionsFile)
+	if err != nil {
+		return fmt.Errorf("error creating fold predictions: %v", err)
+	}
+	defer outFile.Close()
+
+	classes := distinctSorted(labels)
+	writer := csv.NewWriter(outFile)
+	columns := append(append([]string{"Row"}, header...), "Prediction")
+	for _, class := range classes {
+		columns = append(columns, "P_"+class)
+	}
+	writer.Write(columns)
+	for i, idx := range result.Fold.Test {
+		record := append([]string{strconv.Itoa(idx + 1)}, interfaceSliceToStringSlice(dataset[idx])...)
+		record = append(record, result.Predicted[i])
+		probs := Probabilities(tree, rowInstance(header, dataset[idx], len(header)-1))
+		for _, class := range classes {
+			record = append(record, strconv.FormatFloat(probs[class], 'f', 4, 64))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing fold predictions: %v", err)
+	}
+	return nil
+}
+
+// distinctSorted returns the distinct values in sorted order
+func distinctSorted(values []string) []string {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			distinct = append(distinct, v)
+		}
+	}
+	sort.Strings(distinct)
+	return distinct
+}
+
+// PrintCrossValidation prints the per-fold scores with their mean and standard deviation
+func PrintCrossValidation(results []FoldResult) {
+	fmt.Printf("%6s %10s %10s\n", "fold", "accuracy", "f1_macro")
+	accuracies := make([]float64, len(results))
+	f1s := make([]float64, len(results))
+	for i, r := range results {
+		fmt.Printf("%6d %10.4f %10.4f\n", i+1, r.Accuracy, r.F1Macro)
+		accuracies[i], f1s[i] = r.Accuracy, r.F1Macro
+	}
+	accMean, accStd := meanStd(accuracies)
+	f1Mean, f1Std := meanStd(f1s)
+	fmt.Printf("Accuracy: %.4f ± %.4f\n", accMean, accStd)
+	fmt.Printf("F1 (macro): %.4f ± %.4f\n", f1Mean, f1Std)
+}
+
+// CrossValidationMetrics summarises the folds as mean and standard deviation of each metric
+func CrossValidationMetrics(results []FoldResult) map[string]float64 {
+	accuracies := make([]float64, len(results))
+	f1s := make([]float64, len(results))
+	for i, r := range results {
+		accuracies[i], f1s[i] = r.Accuracy, r.F1Macro
+	}
+	accMean, accStd := meanStd(accuracies)
+	f1Mean, f1Std := meanStd(f1s)
+	return map[string]float64{"accuracy": accMean, "accuracy_std": accStd, "f1_macro": f1Mean, "f1_macro_std": f1Std}
+}
+
+// meanStd returns the mean and population standard deviation of the values
+func meanStd(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,88 @@
+package dtree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitDerivation separates "Name = expression" at the first = that is not part of a comparison
+func splitDerivation(derivation string) (string, string, error) {
+	for i := 0; i < len(derivation); i++ {
+		if derivation[i] != '=' {
+			continue
+		}
+		if i+1 < len(derivation) && derivation[i+1] == '=' {
+			i++ // skip ==
+			continue
+		}
+		if i > 0 && strings.ContainsRune("!<>", rune(derivation[i-1])) {
+			continue
+		}
+		name := strings.Trim(strings.TrimSpace(derivation[:i]), "`")
+		expr := strings.TrimSpace(derivation[i+1:])
+		if name == "" || expr == "" {
+			break
+		}
+		return name, expr, nil
+	}
+	return "", "", fmt.Errorf("derivation %q is not of the form Name = expression", derivation)
+}
+
+// DeriveColumns computes a column for each "Name = expression" derivation, in order, so
+// later derivations can use earlier ones. New columns are appended to each row; with
+// keepLast set they go before the last column instead, which keeps a training target last.
+// True/false results are stored as the strings "true" and "false" so they split as categories.
+func DeriveColumns(header []string, dataset [][]interface{}, derivations []string, keepLast bool) ([]string, [][]interface{}, error) {
+	if len(derivations) == 0 {
+		return header, dataset, nil
+	}
+
+	header = append([]string{}, header...)
+	exprs := make([]Expr, len(derivations))
+	for i, derivation := range derivations {
+		name, src, err := splitDerivation(derivation)
+		if err != nil {
+			return nil, nil, err
+		}
+		if findColumn(header, name) != -1 {
+			return nil, nil, fmt.Errorf("derived column %q already exists", name)
+		}
+		exprs[i], err = ParseExpr(src, header)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing derivation of %s: %v", name, err)
+		}
+		header = append(header, name)
+	}
+
+	derived := make([][]interface{}, len(dataset))
+	for i, row := range dataset {
+		out := append(make([]interface{}, 0, len(header)), row...)
+		for _, expr := range exprs {
+			v, err := expr.Eval(out)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error deriving %s for row %d: %v", header[len(out)], i+2, err)
+			}
+			if b, ok := v.(bool); ok {
+				v = fmt.Sprintf("%t", b)
+			}
+			out = append(out, v)
+		}
+		derived[i] = out
+	}
+
+	if keepLast {
+		header = moveToEnd(header, len(header)-len(exprs)-1)
+		for i := range derived {
+			derived[i] = moveToEnd(derived[i], len(derived[i])-len(exprs)-1)
+		}
+	}
+	return header, derived, nil
+}
+
+// moveToEnd moves the element at position i to the end of the slice, in place
+func moveToEnd[T any](values []T, i int) []T {
+	v := values[i]
+	copy(values[i:], values[i+1:])
+	values[len(values)-1] = v
+	return values
+}
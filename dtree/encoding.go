@@ -0,0 +1,108 @@
+package dtree
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// windows1252 maps the bytes 0x80-0x9F, where Windows-1252 differs from Latin-1, to runes.
+// Bytes Windows-1252 leaves undefined keep their Latin-1 meaning (C1 control characters).
+var windows1252 = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+// decodeInput returns a file's contents as UTF-8. With encoding "auto", valid UTF-8 is kept
+// and anything else is read as Windows-1252, which also covers Latin-1 text; "utf-8" and
+// "windows-1252" (or "latin-1") force the choice. A UTF-8 byte order mark is dropped. The
+// bool reports whether the data was converted.
+func decodeInput(data []byte, encoding string) ([]byte, bool, error) {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	switch strings.ToLower(encoding) {
+	case "", "auto":
+		if utf8.Valid(data) {
+			return data, false, nil
+		}
+	case "utf-8", "utf8":
+		return data, false, nil
+	case "windows-1252", "cp1252", "latin-1", "latin1", "iso-8859-1":
+	default:
+		return nil, false, fmt.Errorf("unknown encoding %q (use auto, utf-8 or windows-1252)", encoding)
+	}
+
+	out := make([]byte, 0, len(data)+len(data)/8)
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			out = append(out, b)
+		case b < 0xA0:
+			out = utf8.AppendRune(out, windows1252[b-0x80])
+		default:
+			out = utf8.AppendRune(out, rune(b))
+		}
+	}
+	return out, true, nil
+}
+
+// compositionTable lists, for each combining mark, pairs of a base letter and the
+// precomposed letter it forms with the mark. It covers the Latin-1 Supplement and Latin
+// Extended-A blocks, where legacy European exports put their accented letters.
+var compositionTable = map[rune]string{
+	0x0300: "AÀEÈIÌOÒUÙaàeèiìoòuù",                             // combining grave accent
+	0x0301: "AÁEÉIÍOÓUÚYÝaáeéiíoóuúyýCĆcćLĹlĺNŃnńRŔrŕSŚsśZŹzź", // combining acute accent
+	0x0302: "AÂEÊIÎOÔUÛaâeêiîoôuûCĈcĉGĜgĝHĤhĥJĴjĵSŜsŝWŴwŵYŶyŷ", // combining circumflex accent
+	0x0303: "AÃNÑOÕaãnñoõIĨiĩUŨuũ",                             // combining tilde
+	0x0304: "AĀaāEĒeēIĪiīOŌoōUŪuū",                             // combining macron
+	0x0306: "AĂaăEĔeĕGĞgğIĬiĭOŎoŏUŬuŭ",                         // combining breve
+	0x0307: "CĊcċEĖeėGĠgġIİZŻzż",                               // combining dot above
+	0x0308: "AÄEËIÏOÖUÜaäeëiïoöuüyÿYŸ",                         // combining diaeresis
+	0x030A: "AÅaåUŮuů",                                         // combining ring above
+	0x030B: "OŐoőUŰuű",                                         // combining double acute accent
+	0x030C: "CČcčDĎdďEĚeěLĽlľNŇnňRŘrřSŠsšTŤtťZŽzž",             // combining caron
+	0x0327: "CÇcçGĢgģKĶkķLĻlļNŅnņRŖrŗSŞsşTŢtţ",                 // combining cedilla
+	0x0328: "AĄaąEĘeęIĮiįUŲuų",                                 // combining ogonek
+}
+
+// compositions indexes compositionTable by base letter and mark
+var compositions = func() map[[2]rune]rune {
+	table := make(map[[2]rune]rune)
+	for mark, pairs := range compositionTable {
+		runes := []rune(pairs)
+		for i := 0; i+1 < len(runes); i += 2 {
+			table[[2]rune{runes[i], mark}] = runes[i+1]
+		}
+	}
+	return table
+}()
+
+// composeNFC replaces a Latin letter followed by a combining mark with the precomposed
+// letter, as Unicode NFC does, so "e" plus U+0301 and "é" load as the same category. Only the
+// letters in compositionTable are composed; other text is returned unchanged.
+func composeNFC(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+
+	var out []rune
+	for _, r := range s {
+		if n := len(out); n > 0 {
+			if composed, ok := compositions[[2]rune{out[n-1], r}]; ok {
+				out[n-1] = composed
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
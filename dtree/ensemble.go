@@ -0,0 +1,159 @@
+package dtree
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// shardPath names one shard of a CSV file: big.csv becomes big_part<n>.csv
+func shardPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_part%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// ShardCSV splits a CSV file into parts files that each repeat the header, named after
+// outputFile (or the input when it is empty) as by shardPath. Rows are dealt round-robin,
+// so every shard sees the whole file's mix, and are streamed rather than loaded.
+func ShardCSV(inputFile string, parts int, outputFile string) ([]string, error) {
+	if parts < 2 {
+		return nil, fmt.Errorf("need at least 2 parts, got %d", parts)
+	}
+	if outputFile == "" {
+		outputFile = inputFile
+	}
+
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return nil, openError("error opening file", err)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, withExitCode(ExitParse, fmt.Errorf("error reading header of %s: %v", inputFile, err))
+	}
+
+	paths := make([]string, parts)
+	writers := make([]*csv.Writer, parts)
+	for i := range parts {
+		paths[i] = shardPath(outputFile, i+1)
+		out, err := os.Create(paths[i])
+		if err != nil {
+			return nil, fmt.Errorf("error creating shard file: %v", err)
+		}
+		defer out.Close()
+		writers[i] = csv.NewWriter(out)
+		writers[i].Write(header)
+	}
+
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, withExitCode(ExitParse, fmt.Errorf("error reading file: %v", err))
+		}
+		writers[rows%parts].Write(record)
+		rows++
+	}
+
+	for i, writer := range writers {
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, fmt.Errorf("error writing %s: %v", paths[i], err)
+		}
+	}
+	fmt.Printf("Split %d rows into %d shards of %s\n", rows, parts, outputFile)
+	return paths, nil
+}
+
+// MergeModels combines models trained on separate shards into one voting ensemble saved to
+// outputFile. Ensembles can be merged again; their members are flattened. Every model must
+// have been trained with the same derived columns and load options, which the ensemble keeps.
+func (l *Loader) MergeModels(modelFiles []string, outputFile string) error {
+	if len(modelFiles) < 2 {
+		return fmt.Errorf("need at least 2 models to merge, got %d", len(modelFiles))
+	}
+
+	var first *TreeNode
+	ensemble := &TreeNode{IsLeaf: true, ClassCounts: make(map[string]int)}
+	for _, modelFile := range modelFiles {
+		tree, err := l.LoadModel(modelFile)
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = tree
+		} else if !sameLoadOptions(first, tree) {
+			return fmt.Errorf("%s was trained with different derived columns or load options than %s", modelFile, modelFiles[0])
+		}
+
+		members := tree.Members
+		if len(members) == 0 {
+			members = []*TreeNode{tree}
+		}
+		for _, member := range members {
+			for class, count := range member.ClassCounts {
+				ensemble.ClassCounts[class] += count
+			}
+			member.Derive, member.NATokens, member.Normalize, member.Aliases, member.Skipped = nil, nil, nil, nil, nil
+		}
+		ensemble.Members = append(ensemble.Members, members...)
+	}
+	ensemble.Class = majorityClass(ensemble.ClassCounts)
+	ensemble.Derive, ensemble.NATokens = first.Derive, first.NATokens
+	ensemble.Normalize, ensemble.Aliases = first.Normalize, first.Aliases
+
+	if err := l.SaveModel(ensemble, outputFile); err != nil {
+		return err
+	}
+	fmt.Printf("Merged %d trees into %s\n", len(ensemble.Members), outputFile)
+	return nil
+}
+
+// sameLoadOptions reports whether two models prepare their input the same way
+func sameLoadOptions(a, b *TreeNode) bool {
+	return reflect.DeepEqual(a.Derive, b.Derive) && reflect.DeepEqual(a.NATokens, b.NATokens) &&
+		reflect.DeepEqual(a.Normalize, b.Normalize) && reflect.DeepEqual(a.Aliases, b.Aliases)
+}
+
+// vote predicts with every member of an ensemble and returns the most common answer, breaking
+// ties alphabetically
+func vote(ensemble *TreeNode, instance map[string]string) string {
+	votes := make(map[string]int)
+	for _, member := range ensemble.Members {
+		votes[Predict(member, instance)]++
+	}
+	return majorityClass(votes)
+}
+
+// rowPredictor compiles a model for predicting rows laid out as header, returning a function
+// that predicts one row's formatted values. Ensembles compile each member and vote.
+func rowPredictor(tree *TreeNode, header []string) func(values []string) string {
+	if len(tree.Members) == 0 {
+		compiled := Compile(tree)
+		columns := compiled.Bind(header)
+		return func(values []string) string { return compiled.PredictRow(values, columns) }
+	}
+
+	compiled := make([]*CompiledTree, len(tree.Members))
+	columns := make([][]int, len(tree.Members))
+	for i, member := range tree.Members {
+		compiled[i] = Compile(member)
+		columns[i] = compiled[i].Bind(header)
+	}
+	return func(values []string) string {
+		votes := make(map[string]int)
+		for i, c := range compiled {
+			votes[c.PredictRow(values, columns[i])]++
+		}
+		return majorityClass(votes)
+	}
+}
@@ -0,0 +1,114 @@
+package dtree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// misclassification is one wrong prediction with how sure the model was of it
+type misclassification struct {
+	row        int // data row in the input, counting from 1
+	actual     string
+	predicted  string
+	confidence float64
+	path       []string
+}
+
+// ListErrors predicts every row of a labelled CSV and prints the top most confidently wrong
+// predictions with their decision paths, grouped by actual and predicted class. Groups come
+// largest first, and rows within a group by confidence. It returns the number of
+// misclassified rows and the number scored.
+func (l *Loader) ListErrors(inputFile, modelFile, targetCol string, top int) (int, int, error) {
+	tree, err := l.LoadModel(modelFile)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(tree.Members) > 0 {
+		return 0, 0, fmt.Errorf("errors works on single trees, not ensembles")
+	}
+
+	header, dataset, _, err := l.loadCsv(inputFile, l.modelOptions(tree).withLabels(targetCol))
+	if err != nil {
+		return 0, 0, err
+	}
+	header, dataset, err = DeriveColumns(header, dataset, tree.Derive, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	targetIndex := findColumn(header, targetCol)
+	if targetIndex == -1 {
+		return 0, 0, fmt.Errorf("target column %q not found", targetCol)
+	}
+
+	var wrong []misclassification
+	for i, row := range dataset {
+		instance := rowInstance(header, row, targetIndex)
+		actual, predicted := fmt.Sprintf("%v", row[targetIndex]), Predict(tree, instance)
+		if actual == predicted {
+			continue
+		}
+		wrong = append(wrong, misclassification{
+			row:        i + 1,
+			actual:     actual,
+			predicted:  predicted,
+			confidence: Probabilities(tree, instance)[predicted],
+			path:       decisionPath(tree, instance),
+		})
+	}
+	misclassified := len(wrong)
+	fmt.Printf("%d of %d rows misclassified\n", misclassified, len(dataset))
+
+	sort.SliceStable(wrong, func(a, b int) bool { return wrong[a].confidence > wrong[b].confidence })
+	if top > 0 && len(wrong) > top {
+		wrong = wrong[:top]
+		fmt.Printf("Showing the %d most confident\n", top)
+	}
+	printErrorGroups(wrong)
+	return misclassified, len(dataset), nil
+}
+
+// decisionPath lists the branch conditions an instance follows from the root, stopping where
+// a missing or unseen value leaves it without a child
+func decisionPath(root *TreeNode, instance map[string]string) []string {
+	var path []string
+	for node := root; !node.IsLeaf; {
+		child := childFor(node, instance)
+		if child == nil {
+			return append(path, node.Attribute+" unseen")
+		}
+		for key, c := range node.Children {
+			if c == child {
+				path = append(path, branchCondition(node, key))
+				break
+			}
+		}
+		node = child
+	}
+	return path
+}
+
+// printErrorGroups prints misclassifications grouped by actual and predicted class, keeping
+// the order of the rows within each group
+func printErrorGroups(wrong []misclassification) {
+	type pair struct{ actual, predicted string }
+	groups := make(map[pair][]misclassification)
+	var order []pair
+	for _, m := range wrong {
+		key := pair{m.actual, m.predicted}
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], m)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return len(groups[order[a]]) > len(groups[order[b]]) })
+
+	for _, key := range order {
+		fmt.Printf("\nactual %s, predicted %s (%d)\n", key.actual, key.predicted, len(groups[key]))
+		rows := make([][]string, len(groups[key]))
+		for i, m := range groups[key] {
+			rows[i] = []string{fmt.Sprint(m.row), fmt.Sprintf("%.4f", m.confidence), strings.Join(m.path, ", ")}
+		}
+		printTable([]string{"row", "confidence", "path"}, rows)
+	}
+}
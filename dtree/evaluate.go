@@ -0,0 +1,229 @@
+package dtree
+
+import (
+	"fmt"
+
+	"machineLearning/metrics"
+)
+
+// findColumn returns the index of a column in the header, or -1 if it is absent
+func findColumn(header []string, name string) int {
+	for i, col := range header {
+		if col == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowInstance converts a row into the column-to-value map Predict expects, leaving out the
+// column at skip (pass -1 to keep every column)
+func rowInstance(header []string, row []interface{}, skip int) map[string]string {
+	instance := make(map[string]string)
+	for j, value := range row {
+		switch {
+		case j == skip:
+		case value == nil:
+			instance[header[j]] = "" // missing, as an empty categorical value is
+		default:
+			instance[header[j]] = fmt.Sprintf("%v", value)
+		}
+	}
+	return instance
+}
+
+// EvalOptions selects the optional parts of an evaluation
+type EvalOptions struct {
+	// WeightColumn, when set, names a numeric column whose values weight each row in
+	// case-weighted metrics (see metrics.Report.AddCaseWeights)
+	WeightColumn string
+	// Costs, when set, makes predictions minimise the expected misclassification cost and
+	// adds the total cost to the report
+	Costs CostMatrix
+	// Abstain leaves rows below its confidence threshold out of the metrics and adds the
+	// abstention rate to the report
+	Abstain Abstention
+	// SliceBy names columns whose values each get their own accuracy and F1, for values
+	// with at least MinSupport rows (see metrics.Report.AddSlices)
+	SliceBy    []string
+	MinSupport int
+	// ConfusionFile, when set, receives the confusion matrix as a CSV table or HTML heat map,
+	// cost-weighted when Costs is set (see metrics.ConfusionMatrix.Export)
+	ConfusionFile string
+}
+
+// EvaluateModel predicts every row of a labelled CSV and compares the predictions with the
+// target column, printing a classification report and optionally saving it as JSON. With
+// several targets the CSV is loaded once and each target is scored by its own model and
+// report file, named as by TrainTargets, followed by a combined summary. The reports are
+// returned in target order.
+func (l *Loader) EvaluateModel(inputFile, modelFile string, targetCols []string, reportFile string, opts EvalOptions) ([]*metrics.Report, error) {
+	// Parse missing values the way the (first) model's training data was parsed
+	firstModel := modelFile
+	if len(targetCols) > 1 {
+		firstModel = TargetPath(modelFile, targetCols[0])
+	}
+	tree, err := l.LoadModel(firstModel)
+	if err != nil {
+		return nil, err
+	}
+	header, dataset, _, err := l.loadCsv(inputFile, l.modelOptions(tree).withLabels(targetCols...))
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*metrics.Report, len(targetCols))
+	for i, targetCol := range targetCols {
+		modelPath, reportPath, confusionPath := modelFile, reportFile, opts.ConfusionFile
+		if len(targetCols) > 1 {
+			modelPath = TargetPath(modelFile, targetCol)
+			if reportFile != "" {
+				reportPath = TargetPath(reportFile, targetCol)
+			}
+			if confusionPath != "" {
+				confusionPath = TargetPath(confusionPath, targetCol)
+			}
+			fmt.Printf("== %s (%s)\n", targetCol, modelPath)
+		}
+
+		reports[i], err = l.evaluateTarget(header, dataset, modelPath, targetCol, opts)
+		if err != nil {
+			return nil, err
+		}
+		reports[i].Print()
+
+		if reportPath != "" {
+			if err := reports[i].Save(reportPath); err != nil {
+				return nil, err
+			}
+			fmt.Println("Report saved to", reportPath)
+		}
+		if confusionPath != "" {
+			var cost func(actual, predicted string) float64
+			if opts.Costs != nil {
+				cost = opts.Costs.Cost
+			}
+			if err := reports[i].Confusion.Export(confusionPath, cost); err != nil {
+				return nil, err
+			}
+			fmt.Println("Confusion matrix saved to", confusionPath)
+		}
+	}
+
+	if len(targetCols) > 1 {
+		PrintTargetSummary(targetCols, reports)
+	}
+	return reports, nil
+}
+
+// evaluateTarget scores one model against one target column of a loaded dataset
+func (l *Loader) evaluateTarget(header []string, dataset [][]interface{}, modelFile, targetCol string, opts EvalOptions) (*metrics.Report, error) {
+	tree, err := l.LoadModel(modelFile)
+	if err != nil {
+		return nil, err
+	}
+	header, dataset, err = DeriveColumns(header, dataset, tree.Derive, false)
+	if err != nil {
+		return nil, err
+	}
+
+	targetIndex := findColumn(header, targetCol)
+	if targetIndex == -1 {
+		return nil, fmt.Errorf("target column %q not found", targetCol)
+	}
+
+	costs, abstain, weightCol := opts.Costs, opts.Abstain, opts.WeightColumn
+	var weights []float64
+	if weightCol != "" {
+		weights, err = l.rowWeights(header, dataset, weightCol)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sliceIndex := make([]int, len(opts.SliceBy))
+	for i, col := range opts.SliceBy {
+		if sliceIndex[i] = findColumn(header, col); sliceIndex[i] == -1 {
+			return nil, fmt.Errorf("slice column %q not found", col)
+		}
+	}
+
+	// Abstained rows are dropped, so the metrics describe the predictions actually made
+	actual := make([]string, 0, len(dataset))
+	predicted := make([]string, 0, len(dataset))
+	answeredWeights := make([]float64, 0, len(weights))
+	sliceValues := make([][]string, len(sliceIndex))
+	totalCost, expectedCost := 0.0, 0.0
+	for i, row := range dataset {
+		instance := rowInstance(header, row, targetIndex)
+		if abstain.rejects(Probabilities(tree, instance)) {
+			continue
+		}
+		class, prediction := fmt.Sprintf("%v", row[targetIndex]), Predict(tree, instance)
+		if costs != nil {
+			var expected float64
+			prediction, expected = decideByCost(tree, instance, costs, prediction)
+			expectedCost += expected
+			totalCost += costs.Cost(class, prediction)
+		}
+		actual = append(actual, class)
+		predicted = append(predicted, prediction)
+		if weights != nil {
+			answeredWeights = append(answeredWeights, weights[i])
+		}
+		for s, col := range sliceIndex {
+			sliceValues[s] = append(sliceValues[s], fmt.Sprintf("%v", row[col]))
+		}
+	}
+	if len(actual) == 0 && len(dataset) > 0 {
+		return nil, fmt.Errorf("every row is below min-confidence %.2f; nothing left to evaluate", abstain.MinConfidence)
+	}
+
+	report, err := metrics.Classification(actual, predicted)
+	if err != nil {
+		return nil, err
+	}
+	if costs != nil {
+		report.Metrics["total_cost"] = totalCost
+		report.Metrics["total_expected_cost"] = expectedCost
+	}
+	if abstain.MinConfidence > 0 {
+		report.Metrics["abstention_rate"] = float64(len(dataset)-len(actual)) / float64(len(dataset))
+	}
+	for s, col := range opts.SliceBy {
+		if err := report.AddSlices(col, sliceValues[s], actual, predicted, opts.MinSupport); err != nil {
+			return nil, err
+		}
+	}
+	if weightCol == "" {
+		return report, nil
+	}
+	weights = answeredWeights
+	if err := report.AddCaseWeights(weightCol, actual, predicted, weights); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// rowWeights reads the numeric weight of every row from a column; missing weights count as 0
+func (l *Loader) rowWeights(header []string, dataset [][]interface{}, weightCol string) ([]float64, error) {
+	col := findColumn(header, weightCol)
+	if col == -1 {
+		return nil, fmt.Errorf("weight column %q not found", weightCol)
+	}
+	weights := make([]float64, len(dataset))
+	missing := 0
+	for i, row := range dataset {
+		switch v := row[col].(type) {
+		case float64:
+			weights[i] = v
+		case nil:
+			missing++
+		default:
+			return nil, fmt.Errorf("weight column %q is not numeric: row %d holds %v", weightCol, i+1, v)
+		}
+	}
+	if missing > 0 {
+		l.warn("%d rows have no %s and count with weight 0", missing, weightCol)
+	}
+	return weights, nil
+}
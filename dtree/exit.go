@@ -0,0 +1,80 @@
+package dtree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"machineLearning/metrics"
+)
+
+// Exit codes of the dt command, so scripts can branch on the kind of failure
+const (
+	ExitOK             = 0
+	ExitFailure        = 1 // any failure not listed below
+	ExitUsage          = 2 // missing or invalid flags; the flag package also exits with 2
+	ExitNotFound       = 3 // an input or model file does not exist
+	ExitParse          = 4 // an input or model file could not be parsed
+	ExitTraining       = 5 // a model could not be trained
+	ExitBelowThreshold = 6 // a model scored below -min-accuracy
+)
+
+// exitError attaches an exit code to an error
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode marks an error with the exit code dt should end with
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// UsageError marks an error in how dt was invoked, such as a malformed flag value
+func UsageError(err error) error {
+	return withExitCode(ExitUsage, err)
+}
+
+// withDefaultExitCode marks an error with code unless it already carries one
+func withDefaultExitCode(code int, err error) error {
+	var coded *exitError
+	if errors.As(err, &coded) {
+		return err
+	}
+	return withExitCode(code, err)
+}
+
+// openError reports a file that could not be opened, as not found when it does not exist
+func openError(context string, err error) error {
+	code := ExitFailure
+	if errors.Is(err, fs.ErrNotExist) {
+		code = ExitNotFound
+	}
+	return withExitCode(code, fmt.Errorf("%s: %v", context, err))
+}
+
+// ExitCode returns the exit code for an error: the one attached with withExitCode, or
+// ExitFailure
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var coded *exitError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return ExitFailure
+}
+
+// CheckAccuracy fails with ExitBelowThreshold when a report's accuracy is under the minimum;
+// a minimum of 0 disables the check
+func CheckAccuracy(name string, report *metrics.Report, minAccuracy float64) error {
+	if accuracy := report.Metrics["accuracy"]; minAccuracy > 0 && accuracy < minAccuracy {
+		return withExitCode(ExitBelowThreshold, fmt.Errorf("accuracy %.4f for %s is below the minimum %.4f", accuracy, name, minAccuracy))
+	}
+	return nil
+}
@@ -0,0 +1,427 @@
+package dtree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expr is a compiled expression over the columns of a row, as used by -filter and -derive.
+//
+// The language has number and string literals ('single' or "double" quoted), column names
+// (wrap names that are not plain identifiers in backticks), arithmetic (+ - * /), comparisons
+// (== != < <= > >=), logic (&& || !) and parentheses. Dates compare with each other and with
+// date strings, and subtracting two dates gives the difference in days. Arithmetic on a
+// missing value gives a missing value, and comparisons with one are false.
+type Expr interface {
+	Eval(row []interface{}) (interface{}, error)
+}
+
+// ParseExpr compiles an expression, resolving column names against the header
+func ParseExpr(src string, header []string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, header: header}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in expression", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+// token kinds
+const (
+	tokNumber = iota
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string
+}
+
+// tokenize splits an expression into numbers, strings, identifiers and operators
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i])})
+		case r == '\'' || r == '"' || r == '`':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated %c in expression", r)
+			}
+			kind := tokString
+			if r == '`' {
+				kind = tokIdent
+			}
+			tokens = append(tokens, token{kind, string(runes[i+1 : end])})
+			i = end + 1
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i])})
+		default:
+			op := ""
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "" && strings.ContainsRune("+-*/<>!()", r) {
+				op = string(r)
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q in expression", r)
+			}
+			tokens = append(tokens, token{tokOp, op})
+			i += len([]rune(op))
+		}
+	}
+	return tokens, nil
+}
+
+// exprParser is a recursive descent parser, one method per precedence level
+type exprParser struct {
+	tokens []token
+	pos    int
+	header []string
+}
+
+// accept consumes the next token if it is one of the given operators
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *exprParser) parseComparison() (Expr, error) {
+	return p.parseBinary(p.parseSum, "==", "!=", "<=", ">=", "<", ">")
+}
+
+func (p *exprParser) parseSum() (Expr, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *exprParser) parseProduct() (Expr, error) {
+	return p.parseBinary(p.parseUnary, "*", "/")
+}
+
+// parseBinary parses a left-associative chain of operands joined by the given operators
+func (p *exprParser) parseBinary(operand func() (Expr, error), ops ...string) (Expr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (Expr, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (Expr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	if _, ok := p.accept("("); ok {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing ) in expression")
+		}
+		return inner, nil
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in expression", tok.text)
+		}
+		return literalExpr{value}, nil
+	case tokString:
+		return literalExpr{tok.text}, nil
+	case tokIdent:
+		col := findColumn(p.header, tok.text)
+		if col == -1 {
+			return nil, fmt.Errorf("column %q in expression not found", tok.text)
+		}
+		return columnExpr{col}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in expression", tok.text)
+}
+
+type literalExpr struct{ value interface{} }
+
+func (e literalExpr) Eval(row []interface{}) (interface{}, error) { return e.value, nil }
+
+type columnExpr struct{ col int }
+
+func (e columnExpr) Eval(row []interface{}) (interface{}, error) { return row[e.col], nil }
+
+type unaryExpr struct {
+	op      string
+	operand Expr
+}
+
+func (e unaryExpr) Eval(row []interface{}) (interface{}, error) {
+	v, err := e.operand.Eval(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if e.op == "!" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("! needs a true/false operand, got %v", v)
+		}
+		return !b, nil
+	}
+	num, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("- needs a number, got %v", v)
+	}
+	return -num, nil
+}
+
+type binaryExpr struct {
+	op          string
+	left, right Expr
+}
+
+func (e binaryExpr) Eval(row []interface{}) (interface{}, error) {
+	left, err := e.left.Eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logic short-circuits, so the right side may be skipped
+	if e.op == "&&" || e.op == "||" {
+		l, err := truth(left, e.op)
+		if err != nil || l == (e.op == "||") {
+			return l, err
+		}
+		right, err := e.right.Eval(row)
+		if err != nil {
+			return nil, err
+		}
+		return truth(right, e.op)
+	}
+
+	right, err := e.right.Eval(row)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		if left == nil || right == nil {
+			return false, nil
+		}
+		c, err := compareExprValues(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case "==":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+	return arithmetic(e.op, left, right)
+}
+
+// truth converts an operand of && or || to a bool, treating missing values as false
+func truth(v interface{}, op string) (bool, error) {
+	if v == nil {
+		return false, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s needs true/false operands, got %v", op, v)
+	}
+	return b, nil
+}
+
+// compareExprValues orders two values of the same kind; date strings compare with dates
+func compareExprValues(left, right interface{}) (int, error) {
+	left, right = coerceDates(left, right)
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			return compareOrdered(l, r), nil
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	case time.Time:
+		if r, ok := right.(time.Time); ok {
+			return l.Compare(r), nil
+		}
+	case bool:
+		if r, ok := right.(bool); ok && l == r {
+			return 0, nil
+		} else if ok {
+			return 1, nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %v with %v", left, right)
+}
+
+// coerceDates parses a string operand as a date when the other operand is a date
+func coerceDates(left, right interface{}) (interface{}, interface{}) {
+	if _, ok := left.(time.Time); ok {
+		if s, ok := right.(string); ok {
+			if t, err := parseDate(s); err == nil {
+				right = t
+			}
+		}
+	}
+	if _, ok := right.(time.Time); ok {
+		if s, ok := left.(string); ok {
+			if t, err := parseDate(s); err == nil {
+				left = t
+			}
+		}
+	}
+	return left, right
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// arithmetic applies + - * / to numbers; + also joins strings and - subtracts dates in days
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	if l, ok := left.(string); ok && op == "+" {
+		if r, ok := right.(string); ok {
+			return l + r, nil
+		}
+	}
+	if l, ok := left.(time.Time); ok && op == "-" {
+		if r, ok := right.(time.Time); ok {
+			return l.Sub(r).Hours() / 24, nil
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s needs numbers, got %v and %v", op, left, right)
+	}
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	}
+	if r == 0 {
+		return nil, nil // division by zero leaves the value missing
+	}
+	return l / r, nil
+}
+
+// FilterRows keeps the rows for which the expression is true
+func FilterRows(header []string, dataset [][]interface{}, src string) ([][]interface{}, error) {
+	expr, err := ParseExpr(src, header)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("error parsing filter: %v", err))
+	}
+	var kept [][]interface{}
+	for i, row := range dataset {
+		v, err := expr.Eval(row)
+		if err != nil {
+			return nil, fmt.Errorf("error filtering row %d: %v", i+2, err)
+		}
+		keep, ok := v.(bool)
+		if v != nil && !ok {
+			return nil, fmt.Errorf("filter must be a condition, got %v for row %d", v, i+2)
+		}
+		if keep {
+			kept = append(kept, row)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("filter %q matched no rows", src)
+	}
+	return kept, nil
+}
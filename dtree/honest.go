@@ -0,0 +1,49 @@
+package dtree
+
+import "math/rand"
+
+// honestSplit deals the rows at random into a half that chooses the splits and a half that
+// estimates the class counts
+func honestSplit(dataset [][]interface{}, seed int64) ([][]interface{}, [][]interface{}) {
+	order := rand.New(rand.NewSource(seed)).Perm(len(dataset))
+	structure := make([][]interface{}, 0, len(dataset)/2)
+	estimation := make([][]interface{}, 0, len(dataset)-len(dataset)/2)
+	for i, idx := range order {
+		if i < len(dataset)/2 {
+			structure = append(structure, dataset[idx])
+		} else {
+			estimation = append(estimation, dataset[idx])
+		}
+	}
+	return structure, estimation
+}
+
+// reestimate replaces the class counts of every node, and the class of every leaf, with those
+// of the estimation rows routed through the tree. Nodes no estimation row reaches keep the
+// counts of the rows that grew them.
+func reestimate(tree *TreeNode, header []string, estimation [][]interface{}) {
+	counts := make(map[*TreeNode]map[string]int)
+	for _, row := range estimation {
+		class, ok := row[len(row)-1].(string)
+		if !ok {
+			continue
+		}
+		instance := rowInstance(header, row, len(row)-1)
+		for node := tree; node != nil; node = childFor(node, instance) {
+			if counts[node] == nil {
+				counts[node] = make(map[string]int)
+			}
+			counts[node][class]++
+			if node.IsLeaf {
+				break
+			}
+		}
+	}
+
+	for node, classCounts := range counts {
+		node.ClassCounts = classCounts
+		if node.IsLeaf {
+			node.Class = majorityClass(classCounts)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package dtree
+
+// ValueIndex partitions a dataset's rows by value for every categorical column, built in
+// a single pass. Split finding then fetches each column's subsets by lookup instead of
+// rescanning all rows for every candidate attribute.
+type ValueIndex struct {
+	subsets map[int]map[string][][]interface{}
+}
+
+// IndexDataset builds the value index of every categorical column of the dataset
+func IndexDataset(dataset [][]interface{}) *ValueIndex {
+	idx := &ValueIndex{subsets: make(map[int]map[string][][]interface{})}
+	if len(dataset) == 0 {
+		return idx
+	}
+	for col, value := range dataset[0] {
+		if _, ok := value.(string); ok {
+			idx.subsets[col] = make(map[string][][]interface{})
+		}
+	}
+
+	for _, row := range dataset {
+		for col, values := range idx.subsets {
+			if col < len(row) {
+				key, _ := row[col].(string)
+				values[key] = append(values[key], row)
+			}
+		}
+	}
+	return idx
+}
+
+// Split returns the rows of a categorical column grouped by value, and false if the column
+// is not indexed
+func (idx *ValueIndex) Split(col int) (map[string][][]interface{}, bool) {
+	if idx == nil {
+		return nil, false
+	}
+	subsets, ok := idx.subsets[col]
+	return subsets, ok
+}
+
+// Lookup returns the rows whose categorical column holds value
+func (idx *ValueIndex) Lookup(col int, value string) [][]interface{} {
+	subsets, _ := idx.Split(col)
+	return subsets[value]
+}
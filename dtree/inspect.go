@@ -0,0 +1,171 @@
+package dtree
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// retainLeafSamples routes the training rows through the tree and keeps a uniform sample of
+// up to n of them in each leaf, by reservoir sampling with the seed
+func retainLeafSamples(tree *TreeNode, header []string, dataset [][]interface{}, n int, seed int64) {
+	rng := rand.New(rand.NewSource(seed))
+	seen := make(map[*TreeNode]int)
+	for _, row := range dataset {
+		leaf := leafFor(tree, rowInstance(header, row, -1))
+		if leaf == nil {
+			continue
+		}
+		seen[leaf]++
+		if len(leaf.Samples) < n {
+			leaf.Samples = append(leaf.Samples, interfaceSliceToStringSlice(row))
+		} else if j := rng.Intn(seen[leaf]); j < n {
+			leaf.Samples[j] = interfaceSliceToStringSlice(row)
+		}
+	}
+	tree.SampleColumns = append([]string{}, header...)
+}
+
+// leafFor returns the leaf an instance reaches, or nil if it stops at an unseen value
+func leafFor(root *TreeNode, instance map[string]string) *TreeNode {
+	node := root
+	for node != nil && !node.IsLeaf {
+		node = childFor(node, instance)
+	}
+	return node
+}
+
+// leafPath is a leaf with the branch conditions leading to it
+type leafPath struct {
+	node *TreeNode
+	path []string
+}
+
+// leafPaths lists the leaves depth-first in child key order, which numbers them stably
+func leafPaths(root *TreeNode) []leafPath {
+	var leaves []leafPath
+	var walk func(node *TreeNode, path []string)
+	walk = func(node *TreeNode, path []string) {
+		if node.IsLeaf {
+			leaves = append(leaves, leafPath{node, path})
+			return
+		}
+		keys := make([]string, 0, len(node.Children))
+		for key := range node.Children {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walk(node.Children[key], append(append([]string{}, path...), branchCondition(node, key)))
+		}
+	}
+	walk(root, nil)
+	return leaves
+}
+
+// branchCondition describes the branch to a node's child under key, e.g. "Outlook = Sunny"
+// or "Humidity <=70.00"
+func branchCondition(node *TreeNode, key string) string {
+	if key == fmt.Sprintf("<=%.2f", node.Threshold) || key == fmt.Sprintf(">%.2f", node.Threshold) {
+		return node.Attribute + " " + key
+	}
+	return node.Attribute + " = " + key
+}
+
+// InspectModel lists a model's leaves, or shows one leaf with the training rows it kept: the
+// leaf numbered leaf, or the one an instance given as JSON reaches
+func (l *Loader) InspectModel(modelFile string, leaf int, instanceJSON string) error {
+	tree, err := l.LoadModel(modelFile)
+	if err != nil {
+		return err
+	}
+	if len(tree.Members) > 0 {
+		return fmt.Errorf("inspect works on single trees, not ensembles")
+	}
+	leaves := leafPaths(tree)
+
+	if instanceJSON != "" {
+		instance, err := parseInstance(instanceJSON)
+		if err != nil {
+			return err
+		}
+		reached := leafFor(tree, instance)
+		if reached == nil {
+			return fmt.Errorf("the instance stops before a leaf on a missing or unseen value")
+		}
+		for i, lp := range leaves {
+			if lp.node == reached {
+				leaf = i + 1
+			}
+		}
+	}
+	if leaf == 0 {
+		fmt.Printf("%4s  %-12s  %6s  %4s  %s\n", "leaf", "class", "rows", "kept", "path")
+		for i, lp := range leaves {
+			fmt.Printf("%4d  %-12s  %6d  %4d  %s\n", i+1, lp.node.Class, leafRows(lp.node), len(lp.node.Samples), strings.Join(lp.path, ", "))
+		}
+		return nil
+	}
+	if leaf < 1 || leaf > len(leaves) {
+		return fmt.Errorf("leaf %d does not exist; the model has %d leaves", leaf, len(leaves))
+	}
+
+	lp := leaves[leaf-1]
+	fmt.Printf("Leaf %d: %s\n", leaf, strings.Join(lp.path, ", "))
+	fmt.Printf("Class: %s (%d training rows: %s)\n", lp.node.Class, leafRows(lp.node), formatClassCounts(lp.node.ClassCounts))
+	if len(lp.node.Samples) == 0 {
+		fmt.Println("No training rows were kept; retrain with -leaf-samples to keep some")
+		return nil
+	}
+	fmt.Printf("Training rows kept (%d):\n", len(lp.node.Samples))
+	printTable(tree.SampleColumns, lp.node.Samples)
+	return nil
+}
+
+// leafRows counts the training rows that reached a node
+func leafRows(node *TreeNode) int {
+	rows := 0
+	for _, count := range node.ClassCounts {
+		rows += count
+	}
+	return rows
+}
+
+// formatClassCounts lists class counts in class order, e.g. "No: 3, Yes: 1"
+func formatClassCounts(counts map[string]int) string {
+	classes := make([]string, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		parts[i] = fmt.Sprintf("%s: %d", class, counts[class])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printTable prints rows under a header with every column padded to its widest value
+func printTable(header []string, rows [][]string) {
+	widths := make([]int, len(header))
+	for j, col := range header {
+		widths[j] = len(col)
+		for _, row := range rows {
+			if j < len(row) {
+				widths[j] = max(widths[j], len(row[j]))
+			}
+		}
+	}
+	printRow := func(values []string) {
+		cells := make([]string, len(values))
+		for j, value := range values {
+			cells[j] = fmt.Sprintf("%-*s", widths[j], value)
+		}
+		fmt.Println("  " + strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	printRow(header)
+	for _, row := range rows {
+		printRow(row)
+	}
+}
@@ -0,0 +1,83 @@
+package dtree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InteractionGroup is a named set of features that may appear together on a tree path
+type InteractionGroup struct {
+	Name     string
+	Features []string
+}
+
+// ParseInteractionGroups reads groups written as "geo:lat,lon; time:hour,dow". Names are
+// optional: "lat,lon; hour,dow" numbers the groups instead.
+func ParseInteractionGroups(spec string) ([]InteractionGroup, error) {
+	var groups []InteractionGroup
+	for i, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		group := InteractionGroup{Name: fmt.Sprintf("group%d", i+1)}
+		if name, features, ok := strings.Cut(part, ":"); ok {
+			group.Name, part = strings.TrimSpace(name), features
+		}
+		for _, feature := range strings.Split(part, ",") {
+			if feature = strings.TrimSpace(feature); feature != "" {
+				group.Features = append(group.Features, feature)
+			}
+		}
+		if len(group.Features) == 0 {
+			return nil, fmt.Errorf("interaction group %q lists no features", group.Name)
+		}
+		groups = append(groups, group)
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no interaction groups in %q", spec)
+	}
+	return groups, nil
+}
+
+// checkInteractionGroups reports features the groups name that the dataset does not have
+func checkInteractionGroups(groups []InteractionGroup, header []string) error {
+	for _, group := range groups {
+		for _, feature := range group.Features {
+			if col := findColumn(header, feature); col == -1 || col == len(header)-1 {
+				return fmt.Errorf("interaction group %q names unknown feature %q", group.Name, feature)
+			}
+		}
+	}
+	return nil
+}
+
+// allowedTogether reports whether features may share a path: a single feature always may,
+// several only when one group contains them all
+func allowedTogether(groups []InteractionGroup, features []string) bool {
+	distinct := make(map[string]bool)
+	for _, feature := range features {
+		distinct[feature] = true
+	}
+	if len(distinct) <= 1 {
+		return true
+	}
+	for _, group := range groups {
+		contained := 0
+		for _, feature := range group.Features {
+			if distinct[feature] {
+				contained++
+			}
+		}
+		if contained == len(distinct) {
+			return true
+		}
+	}
+	return false
+}
+
+// extendPath returns path followed by attribute without sharing path's backing array, since
+// sibling subtrees extend the same path
+func extendPath(path []string, attribute string) []string {
+	return append(append([]string{}, path...), attribute)
+}
@@ -0,0 +1,220 @@
+package dtree
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+)
+
+// JoinOptions describes a join of two CSV files on a shared key column
+type JoinOptions struct {
+	Left, Right string
+	On          string
+	// How is "inner" (only keys present in both files) or "left" (every left row, with
+	// empty cells where the right file has no match)
+	How string
+	// Sorted streams both files in one pass, holding only one group of equal right keys in
+	// memory. Both files must be sorted by the key as strings. Without it the right file is
+	// loaded into a hash table and the left file is streamed.
+	Sorted bool
+}
+
+// joinInput is a CSV file being read record by record
+type joinInput struct {
+	name   string
+	file   *os.File
+	reader *csv.Reader
+	header []string
+	key    int
+}
+
+func openJoinInput(filename, on string) (*joinInput, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, openError("error opening file", err)
+	}
+	in := &joinInput{name: filename, file: file, reader: csv.NewReader(file)}
+	in.header, err = in.reader.Read()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading header of %s: %v", filename, err)
+	}
+	in.header = append([]string{}, in.header...)
+	in.key = findColumn(in.header, on)
+	if in.key == -1 {
+		file.Close()
+		return nil, fmt.Errorf("join column %q not found in %s", on, filename)
+	}
+	return in, nil
+}
+
+// next returns the next record, or nil at the end of the file
+func (in *joinInput) next() ([]string, error) {
+	record, err := in.reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", in.name, err)
+	}
+	return record, nil
+}
+
+// JoinCSV joins two CSV files on a key column and writes the result to outputFile. The
+// output has every left column followed by the right columns except the key; right
+// columns whose names clash with left ones get a "_right" suffix.
+func JoinCSV(opts JoinOptions, outputFile string) error {
+	if opts.How != "inner" && opts.How != "left" {
+		return fmt.Errorf("unknown join type %q (use inner or left)", opts.How)
+	}
+
+	left, err := openJoinInput(opts.Left, opts.On)
+	if err != nil {
+		return err
+	}
+	defer left.file.Close()
+	right, err := openJoinInput(opts.Right, opts.On)
+	if err != nil {
+		return err
+	}
+	defer right.file.Close()
+
+	outFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer outFile.Close()
+	writer := csv.NewWriter(outFile)
+
+	header := append([]string{}, left.header...)
+	for j, col := range right.header {
+		if j == right.key {
+			continue
+		}
+		if findColumn(left.header, col) != -1 {
+			col += "_right"
+		}
+		header = append(header, col)
+	}
+	writer.Write(header)
+
+	// emit writes a left row joined with each matching right row
+	rows := 0
+	emit := func(row []string, matches [][]string) {
+		if len(matches) == 0 && opts.How == "left" {
+			matches = [][]string{make([]string, len(right.header))}
+		}
+		for _, match := range matches {
+			out := append([]string{}, row...)
+			for j, value := range match {
+				if j != right.key {
+					out = append(out, value)
+				}
+			}
+			writer.Write(out)
+			rows++
+		}
+	}
+
+	if opts.Sorted {
+		err = mergeJoin(left, right, emit)
+	} else {
+		err = hashJoin(left, right, emit)
+	}
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing output file: %v", err)
+	}
+	fmt.Printf("Joined %d rows into %s\n", rows, outputFile)
+	return nil
+}
+
+// hashJoin loads the right file keyed by the join column and streams the left file past it
+func hashJoin(left, right *joinInput, emit func([]string, [][]string)) error {
+	index := make(map[string][][]string)
+	for {
+		record, err := right.next()
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			break
+		}
+		index[record[right.key]] = append(index[record[right.key]], record)
+	}
+
+	for {
+		record, err := left.next()
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			return nil
+		}
+		emit(record, index[record[left.key]])
+	}
+}
+
+// mergeJoin walks two key-sorted files together, buffering one group of equal right keys
+func mergeJoin(left, right *joinInput, emit func([]string, [][]string)) error {
+	var group [][]string // right rows sharing the current right key
+	groupKey := ""
+	pending, err := right.next()
+	if err != nil {
+		return err
+	}
+
+	// nextGroup reads the right rows with the next key into group
+	nextGroup := func() error {
+		group = group[:0]
+		if pending == nil {
+			return nil
+		}
+		groupKey = pending[right.key]
+		for pending != nil && pending[right.key] == groupKey {
+			group = append(group, pending)
+			if pending, err = right.next(); err != nil {
+				return err
+			}
+		}
+		if pending != nil && pending[right.key] < groupKey {
+			return fmt.Errorf("%s is not sorted by %s: %q follows %q", right.name, right.header[right.key], pending[right.key], groupKey)
+		}
+		return nil
+	}
+	if err := nextGroup(); err != nil {
+		return err
+	}
+
+	lastLeft := ""
+	for first := true; ; first = false {
+		record, err := left.next()
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			return nil
+		}
+		key := record[left.key]
+		if !first && key < lastLeft {
+			return fmt.Errorf("%s is not sorted by %s: %q follows %q", left.name, left.header[left.key], key, lastLeft)
+		}
+		lastLeft = key
+
+		for len(group) > 0 && groupKey < key {
+			if err := nextGroup(); err != nil {
+				return err
+			}
+		}
+		if len(group) > 0 && groupKey == key {
+			emit(record, group)
+		} else {
+			emit(record, nil)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package dtree
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Loader reads CSV files and models with one set of load options and model key, and collects
+// the warnings raised while doing so instead of printing them. Nothing is shared between
+// loaders, so callers with different settings never see each other's, and a Loader is safe
+// for concurrent use.
+type Loader struct {
+	opts LoadOptions
+	key  []byte // encrypts saved models and decrypts loaded ones; nil keeps models as plain JSON
+
+	mu       sync.Mutex
+	warnings []string
+}
+
+// NewLoader returns a loader reading CSV files with opts. A non-nil key is the AES key models
+// are encrypted with when saved and decrypted with when loaded; unencrypted models, which
+// nothing authenticates, are then refused.
+func NewLoader(opts LoadOptions, key []byte) *Loader {
+	return &Loader{opts: opts, key: key}
+}
+
+// Warnings returns what the loader has warned about so far, each warning once, in the order
+// they were raised
+func (l *Loader) Warnings() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.warnings)
+}
+
+// warn records a warning unless an identical one was already raised, as cross-validation
+// folds do for the same column
+func (l *Loader) warn(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !slices.Contains(l.warnings, msg) {
+		l.warnings = append(l.warnings, msg)
+	}
+}
+
+// modelOptions returns the loader's options changed to parse missing values and normalize
+// categories the way the model's training data was loaded; settings the model did not record
+// keep the loader's values
+func (l *Loader) modelOptions(tree *TreeNode) LoadOptions {
+	opts := l.opts
+	if tree.NATokens != nil {
+		opts.NATokens = tree.NATokens
+	}
+	if tree.Normalize != nil {
+		opts.Normalize = *tree.Normalize
+	}
+	opts.Aliases = tree.Aliases
+	return opts
+}
+
+// warnSkipped reports the columns training left out of a tree (see TreeNode.Skipped)
+func (l *Loader) warnSkipped(tree *TreeNode) {
+	for _, name := range slices.Sorted(maps.Keys(tree.Skipped)) {
+		l.warn("not splitting on column %q: %s (use -keep-all-columns to keep it)", name, tree.Skipped[name])
+	}
+}
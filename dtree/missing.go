@@ -0,0 +1,86 @@
+package dtree
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// numericSplit is a threshold split of a numeric column. Rows missing the value are sent to
+// the side that keeps the children purest, XGBoost-style, and that side is remembered so
+// prediction sends missing values the same way.
+type numericSplit struct {
+	threshold   float64
+	left, right [][]interface{}
+	hasMissing  bool // whether any rows lacked the value
+	missingLeft bool
+}
+
+// missingBranch returns the child key missing values follow, or "" when the training rows
+// had none
+func (s numericSplit) missingBranch() string {
+	switch {
+	case !s.hasMissing:
+		return ""
+	case s.missingLeft:
+		return fmt.Sprintf("<=%.2f", s.threshold)
+	}
+	return fmt.Sprintf(">%.2f", s.threshold)
+}
+
+// findThreshold splits a numeric column at the median of its present values and learns the
+// default direction of the rows where it is missing
+func findThreshold(dataset [][]interface{}, attrIndex int) numericSplit {
+	var values []float64
+	for _, row := range dataset {
+		if v, ok := row[attrIndex].(float64); ok {
+			values = append(values, v)
+		} else if v, ok := row[attrIndex].(string); ok {
+			if parsedTime, err := time.Parse("2006-01-02", v); err == nil { // Example: YYYY-MM-DD
+				values = append(values, float64(parsedTime.Unix())) // Convert date to numeric value
+			}
+		}
+	}
+	if len(values) == 0 {
+		// Nothing to split on: every row stays on one side, which callers treat as no split
+		return numericSplit{left: dataset}
+	}
+
+	sort.Float64s(values) // Sort values to find optimal threshold
+	s := numericSplit{threshold: values[len(values)/2]}
+	var missing [][]interface{}
+	for _, row := range dataset {
+		if row[attrIndex] == nil {
+			missing = append(missing, row)
+			continue
+		}
+		val, _ := row[attrIndex].(float64)
+		if val <= s.threshold {
+			s.left = append(s.left, row)
+		} else {
+			s.right = append(s.right, row)
+		}
+	}
+	if len(missing) == 0 {
+		return s
+	}
+
+	// Send the missing rows wherever they leave less weighted entropy, preferring left on ties
+	s.hasMissing = true
+	withLeft := append(append([][]interface{}{}, s.left...), missing...)
+	withRight := append(append([][]interface{}{}, s.right...), missing...)
+	lossLeft := float64(len(withLeft))*Entropy(withLeft) + float64(len(s.right))*Entropy(s.right)
+	lossRight := float64(len(s.left))*Entropy(s.left) + float64(len(withRight))*Entropy(withRight)
+	if lossLeft <= lossRight {
+		s.missingLeft, s.left = true, withLeft
+	} else {
+		s.right = withRight
+	}
+	return s
+}
+
+// missingValue reports whether an instance value stands for a missing one: instances carry
+// missing values, whatever their column type, as the empty value (see rowInstance)
+func missingValue(value string) bool {
+	return value == ""
+}
@@ -0,0 +1,171 @@
+package dtree
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+)
+
+// readRecords returns every record of a CSV file, through a memory mapping when opts.Mmap
+// is set. release must be called once the records are no longer needed.
+func (l *Loader) readRecords(filename string, opts LoadOptions) ([][]string, func(), error) {
+	if opts.Mmap {
+		return l.readRecordsMapped(filename, opts)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, openError("error opening file", err)
+	}
+	data, err = l.decodeFile(filename, data, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, nil, withExitCode(ExitParse, fmt.Errorf("error reading file: %v", err))
+	}
+	return records, func() {}, nil
+}
+
+// decodeFile converts a file's contents to UTF-8 according to opts.Encoding, warning when
+// a legacy encoding was detected
+func (l *Loader) decodeFile(filename string, data []byte, opts LoadOptions) ([]byte, error) {
+	decoded, converted, err := decodeInput(data, opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	if converted {
+		l.warn("%s is not UTF-8; reading it as Windows-1252", filename)
+	}
+	return decoded, nil
+}
+
+// cloneStrings copies strings so they stay valid after a mapped file is released
+func cloneStrings(values []string) []string {
+	cloned := make([]string, len(values))
+	for i, v := range values {
+		cloned[i] = strings.Clone(v)
+	}
+	return cloned
+}
+
+// readRecordsMapped memory-maps a CSV file and splits it into records whose unquoted fields
+// point straight into the mapping instead of being copied. The fields are only valid until
+// release is called, so callers must clone any string they keep.
+func (l *Loader) readRecordsMapped(filename string, opts LoadOptions) ([][]string, func(), error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, openError("error opening file", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening file: %v", err)
+	}
+	if info.Size() == 0 {
+		return nil, func() {}, nil
+	}
+
+	data, unmap, err := mapFile(file, int(info.Size()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error mapping file: %v", err)
+	}
+	release := func() { unmap() }
+
+	// A legacy encoding is converted into a heap buffer, which the records then point into
+	text, err := l.decodeFile(filename, data, opts)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	records, err := splitCSV(text)
+	if err != nil {
+		release()
+		return nil, nil, withExitCode(ExitParse, fmt.Errorf("error reading file: %v", err))
+	}
+	return records, release, nil
+}
+
+// splitCSV parses comma-separated records. Quoted fields may contain commas, newlines
+// and doubled quotes; only fields with doubled quotes need a copy.
+func splitCSV(data []byte) ([][]string, error) {
+	var records [][]string
+	var record []string
+	line := 1
+	pos := 0
+	for pos < len(data) {
+		var field string
+		if data[pos] == '"' {
+			start := pos + 1
+			escaped := false
+			pos = start
+			for {
+				if pos >= len(data) {
+					return nil, fmt.Errorf("line %d: unterminated quoted field", line)
+				}
+				if data[pos] == '"' {
+					if pos+1 < len(data) && data[pos+1] == '"' {
+						escaped = true
+						pos += 2
+						continue
+					}
+					break
+				}
+				if data[pos] == '\n' {
+					line++
+				}
+				pos++
+			}
+			field = byteString(data[start:pos])
+			if escaped {
+				field = strings.ReplaceAll(field, `""`, `"`)
+			}
+			pos++ // closing quote
+		} else {
+			start := pos
+			for pos < len(data) && data[pos] != ',' && data[pos] != '\n' && data[pos] != '\r' {
+				pos++
+			}
+			field = byteString(data[start:pos])
+		}
+		record = append(record, field)
+
+		if pos < len(data) && data[pos] == ',' {
+			pos++
+			continue
+		}
+		if pos < len(data) && data[pos] == '\r' {
+			pos++
+		}
+		if pos < len(data) && data[pos] != '\n' {
+			return nil, fmt.Errorf("line %d: unexpected character after quoted field", line)
+		}
+		pos++
+
+		blank := len(record) == 1 && record[0] == "" // skipped like encoding/csv does
+		if !blank && len(records) > 0 && len(record) != len(records[0]) {
+			return nil, fmt.Errorf("record on line %d: wrong number of fields", line)
+		}
+		if !blank {
+			records = append(records, record)
+		}
+		record = nil
+		line++
+	}
+	return records, nil
+}
+
+// byteString views a byte slice as a string without copying it
+func byteString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(&b[0], len(b))
+}
@@ -0,0 +1,106 @@
+package dtree
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"machineLearning/metrics"
+)
+
+// TargetPath names the file for one of several targets: model.dt becomes model_<target>.dt
+func TargetPath(path, target string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + target + ext
+}
+
+// targetView arranges the dataset for training on one target: the target column moves to
+// the end, where the tree builder expects it, and the other targets are dropped so no model
+// learns from a sibling label. The target must be categorical, since trees predict classes.
+func targetView(header []string, dataset [][]interface{}, target string, targets []string) ([]string, [][]interface{}, error) {
+	targetIndex := findColumn(header, target)
+	if targetIndex == -1 {
+		return nil, nil, fmt.Errorf("target column %q not found", target)
+	}
+	for _, row := range dataset {
+		if _, ok := row[targetIndex].(string); !ok && row[targetIndex] != nil {
+			return nil, nil, fmt.Errorf("target column %q is not categorical (found %v)", target, row[targetIndex])
+		}
+	}
+	var keep []int
+	for j, col := range header {
+		if j != targetIndex && findColumn(targets, col) == -1 {
+			keep = append(keep, j)
+		}
+	}
+	keep = append(keep, targetIndex)
+
+	viewHeader := make([]string, len(keep))
+	for i, j := range keep {
+		viewHeader[i] = header[j]
+	}
+	view := make([][]interface{}, len(dataset))
+	for r, row := range dataset {
+		view[r] = make([]interface{}, len(keep))
+		for i, j := range keep {
+			view[r][i] = row[j]
+		}
+	}
+	return viewHeader, view, nil
+}
+
+// TrainTargets trains an independent model for each target from one loaded dataset, saving
+// them next to outputFile (see TargetPath) and printing each model's training metrics. With a
+// positive minAccuracy no model is saved unless every target reaches it.
+func (l *Loader) TrainTargets(header []string, dataset [][]interface{}, targets []string, outputFile string, opts []Option, derive []string, minAccuracy float64) ([]*metrics.Report, error) {
+	trees := make([]*TreeNode, len(targets))
+	reports := make([]*metrics.Report, len(targets))
+	for i, target := range targets {
+		viewHeader, view, err := targetView(header, dataset, target, targets)
+		if err != nil {
+			return nil, withExitCode(ExitUsage, err)
+		}
+		tree, err := Train(viewHeader, view, opts...)
+		if err != nil {
+			return nil, withDefaultExitCode(ExitTraining, fmt.Errorf("error training %s: %w", target, err))
+		}
+		tree.Derive = derive
+		recordLoadOptions(tree, l.opts)
+		l.warnSkipped(tree)
+		trees[i] = tree
+
+		if reports[i], err = trainingReport(tree, viewHeader, view); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Println("Training metrics:")
+	PrintTargetSummary(targets, reports)
+	for i, target := range targets {
+		if err := CheckAccuracy(target, reports[i], minAccuracy); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, target := range targets {
+		path := TargetPath(outputFile, target)
+		if err := l.SaveModel(trees[i], path); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Model for %s saved to %s\n", target, path)
+	}
+	return reports, nil
+}
+
+// PrintTargetSummary prints one line of headline metrics per target
+func PrintTargetSummary(targets []string, reports []*metrics.Report) {
+	width := len("target")
+	for _, target := range targets {
+		width = max(width, len(target))
+	}
+	fmt.Printf("%-*s  %7s  %8s  %8s\n", width, "target", "samples", "accuracy", "f1_macro")
+	for i, target := range targets {
+		r := reports[i]
+		fmt.Printf("%-*s  %7d  %8.4f  %8.4f\n", width, target, r.Samples, r.Metrics["accuracy"], r.Metrics["f1_macro"])
+	}
+}
@@ -0,0 +1,374 @@
+package dtree
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LoadOptions controls how LoadCsv infers column types
+type LoadOptions struct {
+	// SampleRows is the number of rows, spread evenly over the file, used to infer each
+	// column's type. Zero means every row.
+	SampleRows int
+	// TypeTolerance is the fraction of sampled non-empty values that must parse for a
+	// column to be numeric or a date; values that then fail to parse become missing (nil).
+	TypeTolerance float64
+	// Mmap reads the file through a memory mapping, keeping only categorical values as copies
+	Mmap bool
+	// CacheDir, when set, stores parsed datasets keyed by a hash of the file and these options
+	CacheDir string
+	// Filter, when set, is an expression (see Expr) that rows must satisfy to be loaded
+	Filter string
+	// NATokens are values such as "NA" or "?" that mean missing, like an empty cell. They
+	// become nil in numeric and date columns and the empty value in categorical ones.
+	NATokens []string
+	// Normalize cleans up categorical values so spelling variants share one branch
+	Normalize Normalization
+	// Labels names the target columns, which Normalize leaves as written: class labels are
+	// not spelling variants of one another. An empty name stands for the last column.
+	Labels []string
+	// Encoding of the input files: "auto" detects Windows-1252/Latin-1 files that are not
+	// valid UTF-8, "utf-8" and "windows-1252" force one. Categorical values are always
+	// composed to NFC (see composeNFC).
+	Encoding string
+	// Rename maps column names in the file to the names used from then on
+	Rename map[string]string
+	// Aliases are renames recorded in a model; unlike Rename they only apply when the file
+	// lacks the column the model expects
+	Aliases map[string]string
+	// Strict fails loading on duplicate column names and on values that do not parse as
+	// their column's type, instead of warning and treating them as missing
+	Strict bool
+}
+
+// Normalization selects how categorical feature values are cleaned while loading (see
+// LoadOptions.Labels)
+type Normalization struct {
+	Trim     bool `json:"trim,omitempty"`     // strip leading and trailing whitespace
+	Collapse bool `json:"collapse,omitempty"` // turn runs of internal whitespace into one space
+	FoldCase bool `json:"foldCase,omitempty"` // lower-case every value
+}
+
+// Enabled reports whether any normalization is selected
+func (n Normalization) Enabled() bool {
+	return n.Trim || n.Collapse || n.FoldCase
+}
+
+// Apply normalizes one categorical value
+func (n Normalization) Apply(val string) string {
+	if n.Collapse {
+		val = strings.Join(strings.Fields(val), " ") // also trims, as Fields drops the ends
+	} else if n.Trim {
+		val = strings.TrimSpace(val)
+	}
+	if n.FoldCase {
+		val = strings.ToLower(val)
+	}
+	return val
+}
+
+// isNA reports whether a raw value is empty or one of the configured missing-value tokens
+func (o LoadOptions) isNA(val string) bool {
+	if val == "" {
+		return true
+	}
+	for _, token := range o.NATokens {
+		if val == token {
+			return true
+		}
+	}
+	return false
+}
+
+// isLabel reports whether column col of header is one of the target columns in o.Labels
+func (o LoadOptions) isLabel(header []string, col int) bool {
+	for _, label := range o.Labels {
+		if label == header[col] || (label == "" && col == len(header)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// withLabels returns a copy of o that leaves the given target columns as written
+func (o LoadOptions) withLabels(labels ...string) LoadOptions {
+	o.Labels = labels
+	return o
+}
+
+// normalizeRows returns a copy of dataset with norm applied to every categorical value
+func normalizeRows(dataset [][]interface{}, norm Normalization) [][]interface{} {
+	normalized := make([][]interface{}, len(dataset))
+	for r, row := range dataset {
+		normalized[r] = append([]interface{}{}, row...)
+		for c, val := range row {
+			if s, ok := val.(string); ok {
+				normalized[r][c] = norm.Apply(s)
+			}
+		}
+	}
+	return normalized
+}
+
+// DefaultLoadOptions returns the options used when no flags are given
+func DefaultLoadOptions() LoadOptions {
+	return LoadOptions{SampleRows: 1000, TypeTolerance: 0.99, Encoding: "auto"}
+}
+
+// ColumnReport describes how a column's type was inferred
+type ColumnReport struct {
+	Type        string
+	NumericRate float64
+	DateRate    float64
+	Missing     int
+	FirstBad    int // data row of the first value that became missing, -1 if none
+}
+
+// Ambiguous reports whether most sampled values parsed as numbers or dates but not enough
+// to reach the tolerance, so the column fell back to categorical
+func (r ColumnReport) Ambiguous(tolerance float64) bool {
+	return r.Type == "categorical" && (r.NumericRate >= 0.5 || r.DateRate >= 0.5) &&
+		r.NumericRate < tolerance && r.DateRate < tolerance
+}
+
+// convertColumns infers whether each column is numeric, a date or categorical from a sample
+// of its rows, then converts every value in the same pass. Columns are handed out to a pool
+// of workers, which keeps wide files from being scanned one column at a time.
+func convertColumns(header []string, rawData [][]string, opts LoadOptions) ([][]interface{}, []string, []ColumnReport) {
+	colCount := len(rawData[0])
+	colTypes := make([]string, colCount)
+	reports := make([]ColumnReport, colCount)
+	dataset := make([][]interface{}, len(rawData))
+	for i := range dataset {
+		dataset[i] = make([]interface{}, colCount)
+	}
+
+	columns := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), colCount); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for col := range columns {
+				// Each worker owns whole columns, so writes to dataset never overlap
+				colOpts := opts
+				if opts.isLabel(header, col) {
+					colOpts.Normalize = Normalization{}
+				}
+				reports[col] = convertColumn(rawData, dataset, col, colOpts)
+				colTypes[col] = reports[col].Type
+			}
+		}()
+	}
+	for col := 0; col < colCount; col++ {
+		columns <- col
+	}
+	close(columns)
+	wg.Wait()
+
+	return dataset, colTypes, reports
+}
+
+// convertColumn infers the type of one column, stores the converted values in dataset and
+// returns how the type was chosen
+func convertColumn(rawData [][]string, dataset [][]interface{}, col int, opts LoadOptions) ColumnReport {
+	step := 1
+	if opts.SampleRows > 0 && len(rawData) > opts.SampleRows {
+		step = len(rawData) / opts.SampleRows
+	}
+
+	sampled, numericOK, dateOK := 0, 0, 0
+	for row := 0; row < len(rawData); row += step {
+		val := rawData[row][col]
+		if opts.isNA(val) {
+			continue
+		}
+		sampled++
+		if _, err := strconv.ParseFloat(val, 64); err == nil {
+			numericOK++
+		}
+		if _, err := parseDate(val); err == nil {
+			dateOK++
+		}
+	}
+
+	report := ColumnReport{FirstBad: -1}
+	if sampled > 0 {
+		report.NumericRate = float64(numericOK) / float64(sampled)
+		report.DateRate = float64(dateOK) / float64(sampled)
+	}
+	switch {
+	case sampled > 0 && report.NumericRate >= opts.TypeTolerance:
+		report.Type = "numeric"
+	case sampled > 0 && report.DateRate >= opts.TypeTolerance:
+		report.Type = "date"
+	default:
+		report.Type = "categorical"
+	}
+
+	for row := range rawData {
+		val := rawData[row][col]
+		if opts.isNA(val) {
+			if report.Type == "categorical" {
+				dataset[row][col] = ""
+			}
+			continue // numeric and date columns leave the value nil
+		}
+		switch report.Type {
+		case "numeric":
+			if num, err := strconv.ParseFloat(val, 64); err == nil {
+				dataset[row][col] = num
+			} else {
+				report.missing(row)
+			}
+		case "date":
+			if parsed, err := parseDate(val); err == nil {
+				dataset[row][col] = parsed
+			} else {
+				report.missing(row)
+			}
+		default:
+			val = composeNFC(val)
+			if opts.Normalize.Enabled() {
+				val = opts.Normalize.Apply(val)
+			}
+			if opts.Mmap {
+				val = strings.Clone(val) // the mapping is released after loading
+			}
+			dataset[row][col] = val // Keep as string
+		}
+	}
+	return report
+}
+
+// missing counts a value that failed to parse, remembering the first one
+func (r *ColumnReport) missing(row int) {
+	if r.Missing == 0 {
+		r.FirstBad = row
+	}
+	r.Missing++
+}
+
+// checkStrict returns an error for the first anomaly strict loading rejects: a repeated
+// column name, or a value that does not parse as its column's type. Mostly-numeric or
+// mostly-date columns that fell back to categorical count as anomalies too. Line numbers
+// assume no quoted field spans lines.
+func checkStrict(header []string, rawData [][]string, reports []ColumnReport, opts LoadOptions) error {
+	for i, col := range header {
+		if j := findColumn(header, col); j != i {
+			return fmt.Errorf("line 1: column %q appears more than once (columns %d and %d)", col, j+1, i+1)
+		}
+	}
+
+	for col, r := range reports {
+		row, expected := r.FirstBad, r.Type
+		if r.Ambiguous(opts.TypeTolerance) {
+			expected = "numeric"
+			if r.DateRate > r.NumericRate {
+				expected = "date"
+			}
+			row = firstUnparsable(rawData, col, expected, opts)
+		}
+		if row >= 0 {
+			return fmt.Errorf("line %d, column %q: cannot parse %q as %s", row+2, header[col], rawData[row][col], expected)
+		}
+	}
+	return nil
+}
+
+// firstUnparsable returns the first data row whose value is neither missing nor of the given type
+func firstUnparsable(rawData [][]string, col int, colType string, opts LoadOptions) int {
+	for row := range rawData {
+		val := rawData[row][col]
+		if opts.isNA(val) {
+			continue
+		}
+		var err error
+		if colType == "numeric" {
+			_, err = strconv.ParseFloat(val, 64)
+		} else {
+			_, err = parseDate(val)
+		}
+		if err != nil {
+			return row
+		}
+	}
+	return -1
+}
+
+// reportColumnTypes warns about columns whose type was not clear-cut: typed columns with
+// values that became missing and mostly-typed columns that fell back to categorical
+func (l *Loader) reportColumnTypes(header []string, reports []ColumnReport, opts LoadOptions) {
+	for i, r := range reports {
+		if r.Missing > 0 {
+			l.warn("column %q inferred as %s; %d values could not be parsed and are treated as missing",
+				header[i], r.Type, r.Missing)
+		}
+		if r.Ambiguous(opts.TypeTolerance) {
+			l.warn("column %q is ambiguous (%.1f%% numeric, %.1f%% dates, tolerance %.1f%%); treating as categorical",
+				header[i], 100*r.NumericRate, 100*r.DateRate, 100*opts.TypeTolerance)
+		}
+	}
+}
+
+// PrepareInstance returns a copy of an instance cleaned up the way the model's training data
+// was loaded: columns are renamed by its aliases, its missing-value tokens become empty and
+// its categorical normalization is applied. Models with derived columns are rejected, since
+// their expressions need a typed row rather than raw values.
+func PrepareInstance(tree *TreeNode, instance map[string]string) (map[string]string, error) {
+	if len(tree.Derive) > 0 {
+		return nil, fmt.Errorf("the model derives columns (%s), which single instances do not support", strings.Join(tree.Derive, "; "))
+	}
+	opts := LoadOptions{NATokens: tree.NATokens}
+	if tree.Normalize != nil {
+		opts.Normalize = *tree.Normalize
+	}
+
+	prepared := make(map[string]string, len(instance))
+	for col, val := range instance {
+		if renamed, ok := tree.Aliases[col]; ok {
+			if _, clash := instance[renamed]; !clash {
+				col = renamed
+			}
+		}
+		switch {
+		case opts.isNA(val):
+			val = ""
+		case opts.Normalize.Enabled():
+			val = opts.Normalize.Apply(val)
+		}
+		prepared[col] = val
+	}
+	return prepared, nil
+}
+
+// renameColumns applies the configured renames and model aliases to a header in place
+func renameColumns(header []string, opts LoadOptions) error {
+	for i, col := range header {
+		if renamed, ok := opts.Rename[col]; ok {
+			if findColumn(header, renamed) != -1 {
+				return fmt.Errorf("cannot rename %q to %q: the file already has a %q column", col, renamed, renamed)
+			}
+			header[i] = renamed
+		}
+	}
+	for i, col := range header {
+		if renamed, ok := opts.Aliases[col]; ok && findColumn(header, renamed) == -1 {
+			header[i] = renamed
+		}
+	}
+	return nil
+}
+
+// recordLoadOptions stores the loader settings prediction must repeat in a trained model
+func recordLoadOptions(tree *TreeNode, opts LoadOptions) {
+	tree.NATokens = opts.NATokens
+	tree.Aliases = opts.Rename
+	if opts.Normalize.Enabled() {
+		normalize := opts.Normalize
+		tree.Normalize = &normalize
+	}
+}
@@ -0,0 +1,319 @@
+package dtree
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// DPOptions selects differentially private training; an Epsilon of 0 disables it
+type DPOptions struct {
+	Epsilon  float64
+	MaxDepth int
+	Seed     int64
+	// Domains declares the features the tree may split on; see Domain
+	Domains map[string]Domain
+}
+
+// Domain is the public description of a feature a private tree may split on: the categories
+// it can take, or when Categories is empty, the numeric range [Min, Max]. Domains must come
+// from outside the training rows, such as a schema or domain knowledge, because every
+// category becomes a branch of the published model.
+type Domain struct {
+	Categories []string `json:"categories,omitempty"`
+	Min        float64  `json:"min,omitempty"`
+	Max        float64  `json:"max,omitempty"`
+}
+
+// LoadDomains reads feature domains from a JSON object keyed by column, e.g.
+//
+//	{"Outlook": {"categories": ["Sunny", "Overcast", "Rainy"]}, "Temperature": {"min": 0, "max": 120}}
+func LoadDomains(filename string) (map[string]Domain, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, openError("error opening domains file", err)
+	}
+	defer file.Close()
+
+	var domains map[string]Domain
+	if err := json.NewDecoder(file).Decode(&domains); err != nil {
+		return nil, withExitCode(ExitParse, fmt.Errorf("error decoding domains file %s: %v", filename, err))
+	}
+	return domains, nil
+}
+
+// dpDomain is a Domain as the trainer uses it, with numeric ranges narrowed as the tree descends
+type dpDomain struct {
+	numeric    bool
+	categories []string
+	lo, hi     float64
+}
+
+// dpTrainer holds the state shared by every node of a private tree
+type dpTrainer struct {
+	header   []string
+	classes  []string
+	domains  []dpDomain
+	maxDepth int
+	epsilon  float64 // budget spent by each level of the tree
+	rng      *rand.Rand
+}
+
+// BuildDecisionTreeDP grows a tree of at most opts.MaxDepth levels under epsilon-differential
+// privacy. The budget is split evenly between the split levels and the leaves. Rows reach one
+// node per level, so nodes on the same level share that level's budget; within a node it is
+// divided among the candidate attributes. Split selection uses Laplace-noised class counts,
+// and leaf labels and every stored ClassCounts come from noisy counts too.
+//
+// Only the features given a domain in opts.Domains are split on, and the branches come from
+// those domains alone: categorical features get one child per declared category and numeric
+// features are split at the midpoint of the declared range that reaches the node. The class
+// labels are taken from the training rows and published as leaf classes, so they must not be
+// sensitive themselves.
+func BuildDecisionTreeDP(dataset [][]interface{}, header []string, opts DPOptions) (*TreeNode, error) {
+	if opts.Epsilon <= 0 {
+		return nil, fmt.Errorf("privacy budget must be positive, got %v", opts.Epsilon)
+	}
+	if opts.MaxDepth < 1 {
+		return nil, fmt.Errorf("private trees need a maximum depth of at least 1, got %d", opts.MaxDepth)
+	}
+	domains, err := featureDomains(dataset, header, opts.Domains)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &dpTrainer{
+		header:   header,
+		domains:  domains,
+		maxDepth: opts.MaxDepth,
+		epsilon:  opts.Epsilon / float64(opts.MaxDepth+1),
+		rng:      rand.New(rand.NewSource(opts.Seed)),
+	}
+	for class := range CountClassOccurrences(dataset) {
+		t.classes = append(t.classes, class)
+	}
+	sort.Strings(t.classes)
+
+	return t.build(dataset, append([]dpDomain{}, t.domains...), 0), nil
+}
+
+// featureDomains turns the declared domains into one entry per feature column. Columns
+// without a domain get an empty one, which is never split on.
+func featureDomains(dataset [][]interface{}, header []string, declared map[string]Domain) ([]dpDomain, error) {
+	if len(declared) == 0 {
+		return nil, fmt.Errorf("private trees need the domain of every feature to split on; they are not read from the training rows")
+	}
+	domains := make([]dpDomain, len(header)-1)
+	for name, d := range declared {
+		col := findColumn(header, name)
+		if col == -1 || col == len(header)-1 {
+			return nil, fmt.Errorf("domain given for unknown feature %q", name)
+		}
+
+		numeric := false
+		for _, row := range dataset {
+			if _, ok := row[col].(float64); ok {
+				numeric = true
+				break
+			}
+		}
+		switch {
+		case numeric && len(d.Categories) > 0:
+			return nil, fmt.Errorf("feature %q is numeric but its domain lists categories", name)
+		case numeric && d.Max <= d.Min:
+			return nil, fmt.Errorf("feature %q needs a numeric range with max above min, got [%v, %v]", name, d.Min, d.Max)
+		case numeric:
+			domains[col] = dpDomain{numeric: true, lo: d.Min, hi: d.Max}
+		case len(d.Categories) == 0:
+			return nil, fmt.Errorf("feature %q is categorical but its domain lists no categories", name)
+		default:
+			categories := append([]string{}, d.Categories...)
+			sort.Strings(categories)
+			domains[col] = dpDomain{categories: categories}
+		}
+	}
+	return domains, nil
+}
+
+// build grows the subtree for the rows reaching a node; domains narrows numeric ranges as
+// the tree descends
+func (t *dpTrainer) build(rows [][]interface{}, domains []dpDomain, depth int) *TreeNode {
+	if depth == t.maxDepth {
+		return t.leaf(rows)
+	}
+
+	// Each candidate attribute gets an equal share of this level's budget
+	candidates := 0
+	for _, d := range domains {
+		if d.splittable() {
+			candidates++
+		}
+	}
+	if candidates == 0 {
+		return t.leaf(rows)
+	}
+	eps := t.epsilon / float64(candidates)
+
+	bestCol, bestGain := -1, 0.0
+	for col, d := range domains {
+		if !d.splittable() {
+			continue
+		}
+		counts := make([][]float64, 0)
+		for _, subset := range t.partition(rows, col, d) {
+			counts = append(counts, t.noisyCounts(subset.rows, eps))
+		}
+		if gain := noisyGain(counts); gain > bestGain {
+			bestCol, bestGain = col, gain
+		}
+	}
+	if bestCol == -1 {
+		return t.leaf(rows)
+	}
+
+	node := &TreeNode{Attribute: t.header[bestCol], Children: make(map[string]*TreeNode), ClassCounts: make(map[string]int)}
+	for _, subset := range t.partition(rows, bestCol, domains[bestCol]) {
+		childDomains := append([]dpDomain{}, domains...)
+		childDomains[bestCol] = subset.domain
+		child := t.build(subset.rows, childDomains, depth+1)
+		node.Children[subset.key] = child
+		node.Threshold = subset.threshold
+		if subset.missing {
+			node.MissingBranch = subset.key
+		}
+
+		// Parent counts are sums of the children's noisy counts, so they cost no extra budget
+		for class, count := range child.ClassCounts {
+			node.ClassCounts[class] += count
+		}
+	}
+	return node
+}
+
+// dpSubset is one child of a candidate split
+type dpSubset struct {
+	key       string
+	rows      [][]interface{}
+	domain    dpDomain
+	threshold float64
+	missing   bool // the child rows with a missing value go to, at numeric splits
+}
+
+// partition splits rows on a feature without looking at the data: categorical features get
+// one child per declared category, numeric features are cut at the midpoint of their range.
+// Rows whose category was not declared reach no child.
+func (t *dpTrainer) partition(rows [][]interface{}, col int, d dpDomain) []dpSubset {
+	if !d.numeric {
+		subsets := make([]dpSubset, len(d.categories))
+		position := make(map[string]int, len(d.categories))
+		for i, category := range d.categories {
+			subsets[i] = dpSubset{key: category, domain: dpDomain{categories: []string{category}}}
+			position[category] = i
+		}
+		for _, row := range rows {
+			if v, ok := row[col].(string); ok {
+				if i, found := position[v]; found {
+					subsets[i].rows = append(subsets[i].rows, row)
+				}
+			}
+		}
+		return subsets
+	}
+
+	threshold := (d.lo + d.hi) / 2
+	left := dpSubset{key: fmt.Sprintf("<=%.2f", threshold), domain: dpDomain{numeric: true, lo: d.lo, hi: threshold}, threshold: threshold, missing: true}
+	right := dpSubset{key: fmt.Sprintf(">%.2f", threshold), domain: dpDomain{numeric: true, lo: threshold, hi: d.hi}, threshold: threshold}
+	for _, row := range rows {
+		// Missing values go to the missing child, which build records as the MissingBranch so
+		// prediction routes them the same way; learning their direction would depend on the data
+		val, ok := row[col].(float64)
+		if !ok || val <= threshold {
+			left.rows = append(left.rows, row)
+		} else {
+			right.rows = append(right.rows, row)
+		}
+	}
+	return []dpSubset{left, right}
+}
+
+// splittable reports whether a split on the domain could separate any rows
+func (d dpDomain) splittable() bool {
+	if d.numeric {
+		return d.hi > d.lo
+	}
+	return len(d.categories) > 1
+}
+
+// leaf labels a node with the largest noisy class count
+func (t *dpTrainer) leaf(rows [][]interface{}) *TreeNode {
+	noisy := t.noisyCounts(rows, t.epsilon)
+	classCounts := make(map[string]int, len(t.classes))
+	for i, class := range t.classes {
+		classCounts[class] = int(math.Round(noisy[i]))
+	}
+	return &TreeNode{Class: majorityClass(classCounts), IsLeaf: true, ClassCounts: classCounts}
+}
+
+// noisyCounts returns the class counts of rows with Laplace noise of scale 1/eps added. One
+// row changes one count by one, so the histogram has sensitivity 1. Counts are clamped at zero.
+func (t *dpTrainer) noisyCounts(rows [][]interface{}, eps float64) []float64 {
+	exact := CountClassOccurrences(rows)
+	counts := make([]float64, len(t.classes))
+	for i, class := range t.classes {
+		counts[i] = math.Max(0, float64(exact[class])+laplace(t.rng, 1/eps))
+	}
+	return counts
+}
+
+// laplace draws from a zero-mean Laplace distribution with the given scale
+func laplace(rng *rand.Rand, scale float64) float64 {
+	u := rng.Float64() - 0.5
+	return -scale * math.Copysign(1, u) * math.Log(1-2*math.Abs(u))
+}
+
+// noisyGain is the information gain of a split computed from per-child class counts
+func noisyGain(children [][]float64) float64 {
+	var parent []float64
+	total := 0.0
+	for _, counts := range children {
+		if parent == nil {
+			parent = make([]float64, len(counts))
+		}
+		for i, c := range counts {
+			parent[i] += c
+			total += c
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	weighted := 0.0
+	for _, counts := range children {
+		size := 0.0
+		for _, c := range counts {
+			size += c
+		}
+		weighted += size / total * countEntropy(counts)
+	}
+	return countEntropy(parent) - weighted
+}
+
+// countEntropy is the entropy of a class histogram
+func countEntropy(counts []float64) float64 {
+	total := 0.0
+	for _, c := range counts {
+		total += c
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := c / total
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
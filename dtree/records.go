@@ -0,0 +1,111 @@
+package dtree
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TrainFromRecords grows a decision tree on rows held in memory as strings, typed the way
+// the loader types a file, so callers that already have their data need not write a CSV first.
+// The last column is the target, as for Train.
+//
+//	tree, err := loader.TrainFromRecords([]string{"Outlook", "Humidity", "Play"}, rows, WithMaxDepth(4))
+func (l *Loader) TrainFromRecords(header []string, rows [][]string, opts ...Option) (*TreeNode, error) {
+	dataset, err := convertRecords(header, rows, l.opts.withLabels("")) // the target is last
+	if err != nil {
+		return nil, err
+	}
+	tree, err := Train(header, dataset, opts...)
+	if err != nil {
+		return nil, err
+	}
+	recordLoadOptions(tree, l.opts)
+	l.warnSkipped(tree)
+	return tree, nil
+}
+
+// convertRecords checks that rows match the header and converts them as LoadCsv would
+func convertRecords(header []string, rows [][]string, opts LoadOptions) ([][]interface{}, error) {
+	if len(header) < 2 {
+		return nil, fmt.Errorf("need at least one feature and a target column, got %d columns", len(header))
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no training rows")
+	}
+	for i, row := range rows {
+		if len(row) != len(header) {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(header))
+		}
+	}
+	dataset, _, _ := convertColumns(header, rows, opts)
+	return dataset, nil
+}
+
+// Column is one named column of already typed values, for TrainFromColumns
+type Column struct {
+	Name   string
+	Values []interface{}
+}
+
+// NumericColumn builds a numeric column; NaN values are missing
+func NumericColumn(name string, values []float64) Column {
+	col := Column{Name: name, Values: make([]interface{}, len(values))}
+	for i, v := range values {
+		if !math.IsNaN(v) {
+			col.Values[i] = v
+		}
+	}
+	return col
+}
+
+// CategoricalColumn builds a categorical column; empty values are missing
+func CategoricalColumn(name string, values []string) Column {
+	col := Column{Name: name, Values: make([]interface{}, len(values))}
+	for i, v := range values {
+		col.Values[i] = v
+	}
+	return col
+}
+
+// DateColumn builds a date column; zero times are missing
+func DateColumn(name string, values []time.Time) Column {
+	col := Column{Name: name, Values: make([]interface{}, len(values))}
+	for i, v := range values {
+		if !v.IsZero() {
+			col.Values[i] = v
+		}
+	}
+	return col
+}
+
+// TrainFromColumns grows a decision tree on typed columns of equal length, skipping type
+// inference. The last column is the target.
+//
+//	tree, err := TrainFromColumns([]Column{
+//		NumericColumn("Age", ages),
+//		CategoricalColumn("Plan", plans),
+//		CategoricalColumn("Churned", churned),
+//	}, WithCriterion(Gini))
+func TrainFromColumns(columns []Column, opts ...Option) (*TreeNode, error) {
+	if len(columns) < 2 {
+		return nil, fmt.Errorf("need at least one feature and a target column, got %d columns", len(columns))
+	}
+	header := make([]string, len(columns))
+	rowCount := len(columns[0].Values)
+	for i, col := range columns {
+		if len(col.Values) != rowCount {
+			return nil, fmt.Errorf("column %q has %d values, expected %d", col.Name, len(col.Values), rowCount)
+		}
+		header[i] = col.Name
+	}
+
+	dataset := make([][]interface{}, rowCount)
+	for r := range dataset {
+		dataset[r] = make([]interface{}, len(columns))
+		for c, col := range columns {
+			dataset[r][c] = col.Values[r]
+		}
+	}
+	return Train(header, dataset, opts...)
+}
@@ -0,0 +1,36 @@
+package dtree
+
+import (
+	"iter"
+
+	"machineLearning/frame"
+)
+
+// Prediction is a model's answer for one row
+type Prediction struct {
+	Class         string
+	Probabilities map[string]float64 // class shares of the node the row reached; nil for models without class counts
+}
+
+// PredictAll yields every row of a frame with the tree's prediction for it, computed as the
+// loop asks for it. The model is compiled once; breaking out of the loop stops scoring.
+//
+//	for row, p := range PredictAll(tree, f) {
+//		fmt.Println(row.String("id"), p.Class)
+//	}
+func PredictAll(tree *TreeNode, f *frame.Frame) iter.Seq2[frame.Row, Prediction] {
+	return func(yield func(frame.Row, Prediction) bool) {
+		header := f.Columns()
+		predict := rowPredictor(tree, header)
+		for row := range f.Rows() {
+			values := interfaceSliceToStringSlice(row.Values())
+			prediction := Prediction{
+				Class:         predict(values),
+				Probabilities: Probabilities(tree, rowInstance(header, row.Values(), -1)),
+			}
+			if !yield(row, prediction) {
+				return
+			}
+		}
+	}
+}
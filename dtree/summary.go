@@ -0,0 +1,133 @@
+package dtree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// summaryBins is the number of equal-width bins in the confidence histogram
+const summaryBins = 10
+
+// Probabilities returns the class shares of the deepest node the instance reaches: its leaf,
+// or the node where an unseen value stopped the walk. Models without class counts give nil.
+func Probabilities(root *TreeNode, instance map[string]string) map[string]float64 {
+	if len(root.Members) > 0 {
+		return memberProbabilities(root, instance)
+	}
+	node := root
+	for !node.IsLeaf {
+		child := childFor(node, instance)
+		if child == nil {
+			break
+		}
+		node = child
+	}
+	if node.ClassCounts == nil {
+		return nil
+	}
+	probs := make(map[string]float64, len(node.ClassCounts))
+	for class := range node.ClassCounts {
+		probs[class] = classShare(node, class)
+	}
+	return probs
+}
+
+// memberProbabilities averages the class probabilities of an ensemble's members, or returns
+// nil when any member lacks class counts
+func memberProbabilities(ensemble *TreeNode, instance map[string]string) map[string]float64 {
+	probs := make(map[string]float64)
+	for _, member := range ensemble.Members {
+		memberProbs := Probabilities(member, instance)
+		if memberProbs == nil {
+			return nil
+		}
+		for class, p := range memberProbs {
+			probs[class] += p / float64(len(ensemble.Members))
+		}
+	}
+	return probs
+}
+
+// PredictionSummary accumulates what a batch of predictions looks like, so a scoring run can
+// be sanity-checked before its results are used
+type PredictionSummary struct {
+	Rows       int
+	Predicted  map[string]int
+	ProbSum    map[string]float64
+	Confidence [summaryBins]int // histogram of the predicted class's probability
+	noProbs    int
+}
+
+// NewPredictionSummary creates an empty summary
+func NewPredictionSummary() *PredictionSummary {
+	return &PredictionSummary{Predicted: make(map[string]int), ProbSum: make(map[string]float64)}
+}
+
+// Add records one prediction and the class probabilities behind it (nil when unknown)
+func (s *PredictionSummary) Add(prediction string, probs map[string]float64) {
+	s.Rows++
+	s.Predicted[prediction]++
+	if probs == nil {
+		s.noProbs++
+		return
+	}
+	for class, p := range probs {
+		s.ProbSum[class] += p
+	}
+	bin := min(int(probs[prediction]*summaryBins), summaryBins-1)
+	s.Confidence[bin]++
+}
+
+// Print writes the predicted class distribution, the mean probability of each class and a
+// histogram of prediction confidence
+func (s *PredictionSummary) Print() {
+	fmt.Printf("prediction summary over %d rows\n", s.Rows)
+
+	classes := make([]string, 0, len(s.Predicted))
+	seen := make(map[string]bool)
+	for class := range s.Predicted {
+		classes, seen[class] = append(classes, class), true
+	}
+	for class := range s.ProbSum {
+		if !seen[class] {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+
+	width := len("class")
+	for _, class := range classes {
+		width = max(width, len(class))
+	}
+	scored := s.Rows - s.noProbs
+	fmt.Printf("  %-*s  %9s  %6s  %9s\n", width, "class", "predicted", "share", "mean_prob")
+	for _, class := range classes {
+		meanProb := 0.0
+		if scored > 0 {
+			meanProb = s.ProbSum[class] / float64(scored)
+		}
+		fmt.Printf("  %-*s  %9d  %6.3f  %9.4f\n", width, class, s.Predicted[class], float64(s.Predicted[class])/float64(s.Rows), meanProb)
+	}
+
+	if scored == 0 {
+		fmt.Println("  no class probabilities: the model has no class counts")
+		return
+	}
+	fmt.Println("  confidence (probability of the predicted class)")
+	largest := 0
+	for _, n := range s.Confidence {
+		largest = max(largest, n)
+	}
+	for bin, n := range s.Confidence {
+		bar := 0
+		if largest > 0 {
+			bar = n * 40 / largest
+		}
+		lo, hi := float64(bin)/summaryBins, float64(bin+1)/summaryBins
+		fmt.Printf("  %.1f-%.1f  %6d  %s\n", lo, hi, n, strings.Repeat("#", bar))
+	}
+	if s.noProbs > 0 {
+		fmt.Printf("  %d rows had no class probabilities\n", s.noProbs)
+	}
+}
@@ -0,0 +1,208 @@
+package dtree
+
+import (
+	"sort"
+	"strconv"
+)
+
+// maxSurrogates is the most surrogate splits kept per node
+const maxSurrogates = 3
+
+// Surrogate is a split on another feature that mimics a node's primary split, CART-style.
+// Rows missing the primary feature are routed by the first surrogate whose feature they have.
+type Surrogate struct {
+	Attribute string
+	// Numeric surrogates send values up to Threshold to the Below child and the rest Above
+	Threshold float64 `json:"Threshold,omitempty"`
+	Below     string  `json:"Below,omitempty"`
+	Above     string  `json:"Above,omitempty"`
+	// Categorical surrogates map each category to a child
+	Categories map[string]string `json:"Categories,omitempty"`
+	// Agreement is the share of training rows the surrogate routed like the primary split
+	Agreement float64
+}
+
+// route returns the child key the surrogate sends a value to
+func (s Surrogate) route(value string) (string, bool) {
+	if s.Categories != nil {
+		key, ok := s.Categories[value]
+		return key, ok
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", false
+	}
+	if v <= s.Threshold {
+		return s.Below, true
+	}
+	return s.Above, true
+}
+
+// childFor returns the child an instance goes to, or nil if it cannot be routed. A missing or
+// absent primary value is routed by the node's surrogates, then by its default branch.
+func childFor(node *TreeNode, instance map[string]string) *TreeNode {
+	attrValue, exists := instance[node.Attribute]
+	if exists {
+		if child := nextNode(node, attrValue); child != nil {
+			return child
+		}
+	}
+	if exists && !missingValue(attrValue) {
+		return nil
+	}
+	for _, s := range node.Surrogates {
+		value, ok := instance[s.Attribute]
+		if !ok || missingValue(value) {
+			continue
+		}
+		if key, ok := s.route(value); ok && node.Children[key] != nil {
+			return node.Children[key]
+		}
+	}
+	if node.MissingBranch != "" {
+		return node.Children[node.MissingBranch]
+	}
+	return nil
+}
+
+// labelledRow is a training row with the child its primary split sent it to
+type labelledRow struct {
+	row []interface{}
+	key string
+}
+
+// findSurrogates ranks the other features by how well they reproduce a node's split into
+// subsets, keeping up to maxSurrogates that beat sending every row to the largest child.
+// Numeric surrogates are only searched for two-way splits, and only on the columns usable
+// reports true for.
+func findSurrogates(subsets map[string][][]interface{}, header []string, attrIndex int, usable func(string) bool) []Surrogate {
+	var rows []labelledRow
+	largest := 0
+	for key, subset := range subsets {
+		present := 0
+		for _, row := range subset {
+			if row[attrIndex] != nil && row[attrIndex] != "" {
+				rows = append(rows, labelledRow{row, key})
+				present++
+			}
+		}
+		largest = max(largest, present)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	majorityShare := float64(largest) / float64(len(rows))
+
+	var binary []string
+	if len(subsets) == 2 {
+		for key := range subsets {
+			binary = append(binary, key)
+		}
+		sort.Strings(binary)
+	}
+
+	var surrogates []Surrogate
+	for col, name := range header[:len(header)-1] {
+		if col == attrIndex || !usable(name) {
+			continue
+		}
+		s, ok := categoricalSurrogate(rows, col)
+		if !ok && binary != nil {
+			s, ok = numericSurrogate(rows, col, binary[0], binary[1])
+		}
+		if ok && s.Agreement > majorityShare {
+			s.Attribute = name
+			surrogates = append(surrogates, s)
+		}
+	}
+	sort.Slice(surrogates, func(i, j int) bool {
+		if surrogates[i].Agreement != surrogates[j].Agreement {
+			return surrogates[i].Agreement > surrogates[j].Agreement
+		}
+		return surrogates[i].Attribute < surrogates[j].Attribute
+	})
+	return surrogates[:min(len(surrogates), maxSurrogates)]
+}
+
+// categoricalSurrogate sends each category of a string column to the child most of its rows
+// went to, and reports false when the column is not categorical
+func categoricalSurrogate(rows []labelledRow, col int) (Surrogate, bool) {
+	counts := make(map[string]map[string]int)
+	present := 0
+	for _, r := range rows {
+		value, ok := r.row[col].(string)
+		if !ok {
+			if r.row[col] != nil {
+				return Surrogate{}, false
+			}
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		if counts[value] == nil {
+			counts[value] = make(map[string]int)
+		}
+		counts[value][r.key]++
+		present++
+	}
+	if present == 0 {
+		return Surrogate{}, false
+	}
+
+	s := Surrogate{Categories: make(map[string]string, len(counts))}
+	agreed := 0
+	for value, keys := range counts {
+		key := majorityClass(keys)
+		s.Categories[value] = key
+		agreed += keys[key]
+	}
+	s.Agreement = float64(agreed) / float64(present)
+	return s, true
+}
+
+// numericSurrogate finds the threshold on a numeric column, and the orientation, that best
+// separates the rows sent to the below and above children
+func numericSurrogate(rows []labelledRow, col int, below, above string) (Surrogate, bool) {
+	type point struct {
+		value float64
+		below bool
+	}
+	var points []point
+	totalBelow := 0
+	for _, r := range rows {
+		if v, ok := r.row[col].(float64); ok {
+			points = append(points, point{v, r.key == below})
+			if r.key == below {
+				totalBelow++
+			}
+		}
+	}
+	if len(points) == 0 {
+		return Surrogate{}, false
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].value < points[j].value })
+
+	best := Surrogate{}
+	belowSoFar := 0
+	for i, p := range points {
+		if p.below {
+			belowSoFar++
+		}
+		if i+1 < len(points) && points[i+1].value == p.value {
+			continue
+		}
+		// Agreement with values up to p sent below, and with the orientation flipped
+		aboveAfter := (len(points) - i - 1) - (totalBelow - belowSoFar)
+		agreed := belowSoFar + aboveAfter
+		flipped := len(points) - agreed
+		if agreed > int(best.Agreement) {
+			best = Surrogate{Threshold: p.value, Below: below, Above: above, Agreement: float64(agreed)}
+		}
+		if flipped > int(best.Agreement) {
+			best = Surrogate{Threshold: p.value, Below: above, Above: below, Agreement: float64(flipped)}
+		}
+	}
+	best.Agreement /= float64(len(points))
+	return best, best.Below != ""
+}
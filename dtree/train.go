@@ -0,0 +1,369 @@
+package dtree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Criterion is the impurity measure splits are chosen by
+type Criterion string
+
+const (
+	InfoGainRatio Criterion = "gain-ratio" // information gain normalised by split information (the default)
+	InfoGain      Criterion = "entropy"    // reduction in entropy
+	Gini          Criterion = "gini"       // reduction in Gini impurity
+)
+
+// defaultPrivateDepth bounds private trees trained without WithMaxDepth
+const defaultPrivateDepth = 4
+
+// TrainConfig holds the settings Train grows a tree with. Build one with Options rather than
+// setting fields directly; the zero value of every field means "no limit" or "off".
+type TrainConfig struct {
+	MaxDepth  int       // deepest level a split may occur at; 0 = unlimited
+	MaxLeaves int       // grow best-first up to this many leaves; 0 = grow depth-first
+	Criterion Criterion // impurity measure; empty means InfoGainRatio
+	Seed      int64     // seed for every random choice made while training
+	Epsilon   float64   // differential privacy budget; 0 = off
+	// Domains declares the features a private tree may split on and the values they take
+	Domains map[string]Domain
+	// LeafSamples is how many training rows each leaf keeps for inspection; 0 = none
+	LeafSamples int
+	// KeepAllColumns lets Train split on constant and ID-like columns, which it otherwise skips.
+	// Private trees never skip them; leave such columns out of Domains instead.
+	KeepAllColumns bool
+	// InteractionGroups restricts the features a path may combine to those sharing a group
+	InteractionGroups []InteractionGroup
+	// FeaturePenalties multiplies the split score of each listed feature; below 1 discourages it
+	FeaturePenalties map[string]float64
+	// Honest grows the splits on one half of the rows and estimates class counts on the other
+	Honest bool
+	// MinSamplesSplit is the fewest rows a node needs to be split; 0 = no minimum
+	MinSamplesSplit int
+	// MinSamplesLeaf is the fewest rows a split may leave in any child; 0 = no minimum
+	MinSamplesLeaf int
+
+	excluded map[string]string // columns splits may not use, with the reason
+}
+
+// Option configures Train
+type Option func(*TrainConfig)
+
+// WithMaxDepth stops splitting at depth levels below the root, emitting majority-class leaves
+func WithMaxDepth(depth int) Option {
+	return func(c *TrainConfig) { c.MaxDepth = depth }
+}
+
+// WithMaxLeaves grows the tree best-first, always splitting the most improving leaf, until it
+// has n leaves
+func WithMaxLeaves(n int) Option {
+	return func(c *TrainConfig) { c.MaxLeaves = n }
+}
+
+// WithCriterion chooses the impurity measure splits are ranked by
+func WithCriterion(criterion Criterion) Option {
+	return func(c *TrainConfig) { c.Criterion = criterion }
+}
+
+// WithSeed seeds the random choices training makes, so runs can be reproduced
+func WithSeed(seed int64) Option {
+	return func(c *TrainConfig) { c.Seed = seed }
+}
+
+// WithPrivacy trains under epsilon-differential privacy (see BuildDecisionTreeDP), splitting
+// only on the features given a domain. Private trees always use entropy on noisy counts and
+// default to a depth of 4.
+func WithPrivacy(epsilon float64, domains map[string]Domain) Option {
+	return func(c *TrainConfig) { c.Epsilon, c.Domains = epsilon, domains }
+}
+
+// WithLeafSamples keeps up to n training rows in every leaf, sampled with the seed, so
+// inspect -leaf can show the examples behind a prediction. Models grow accordingly and carry
+// training data, so private trees refuse it.
+func WithLeafSamples(n int) Option {
+	return func(c *TrainConfig) { c.LeafSamples = n }
+}
+
+// WithAllColumns lets training split on every column, including the constant and ID-like
+// ones Train skips by default
+func WithAllColumns() Option {
+	return func(c *TrainConfig) { c.KeepAllColumns = true }
+}
+
+// WithInteractionGroups only lets features split on the same path when one group holds
+// them all, e.g. geo:lat,lon and time:hour,dow keep location and time effects apart.
+// Features outside every group can only be combined with themselves, and such trees keep no
+// surrogate splits.
+func WithInteractionGroups(groups ...InteractionGroup) Option {
+	return func(c *TrainConfig) { c.InteractionGroups = groups }
+}
+
+// WithFeaturePenalty multiplies every split score of a feature by multiplier, so a value
+// such as 0.5 makes training prefer other features unless this one is clearly better. It
+// suits features that are expensive to collect or unstable, without excluding them.
+func WithFeaturePenalty(feature string, multiplier float64) Option {
+	return func(c *TrainConfig) {
+		if c.FeaturePenalties == nil {
+			c.FeaturePenalties = make(map[string]float64)
+		}
+		c.FeaturePenalties[feature] = multiplier
+	}
+}
+
+// WithHonesty grows an honest tree: a random half of the rows, drawn with the seed, chooses
+// the splits and the other half estimates every node's class counts and each leaf's class.
+// Class probabilities are then not fitted to the rows that shaped the tree, so they are
+// better calibrated, at the cost of fewer rows for each task.
+func WithHonesty() Option {
+	return func(c *TrainConfig) { c.Honest = true }
+}
+
+// WithMinSamplesSplit turns nodes with fewer than n training rows into majority-class leaves
+// instead of splitting them
+func WithMinSamplesSplit(n int) Option {
+	return func(c *TrainConfig) { c.MinSamplesSplit = n }
+}
+
+// WithMinSamplesLeaf only considers splits that leave at least n training rows in every
+// child; a node with no such split becomes a majority-class leaf
+func WithMinSamplesLeaf(n int) Option {
+	return func(c *TrainConfig) { c.MinSamplesLeaf = n }
+}
+
+// NewTrainConfig applies options to the default configuration and checks the result
+func NewTrainConfig(opts ...Option) (TrainConfig, error) {
+	cfg := TrainConfig{Criterion: InfoGainRatio}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.Criterion == "" {
+		cfg.Criterion = InfoGainRatio
+	}
+
+	switch {
+	case cfg.MaxDepth < 0:
+		return cfg, fmt.Errorf("max depth must not be negative, got %d", cfg.MaxDepth)
+	case cfg.MaxLeaves < 0:
+		return cfg, fmt.Errorf("max leaves must not be negative, got %d", cfg.MaxLeaves)
+	case cfg.Epsilon < 0:
+		return cfg, fmt.Errorf("privacy budget must not be negative, got %v", cfg.Epsilon)
+	case cfg.MinSamplesSplit < 0:
+		return cfg, fmt.Errorf("min samples per split must not be negative, got %d", cfg.MinSamplesSplit)
+	case cfg.MinSamplesLeaf < 0:
+		return cfg, fmt.Errorf("min samples per leaf must not be negative, got %d", cfg.MinSamplesLeaf)
+	case (cfg.MinSamplesSplit > 0 || cfg.MinSamplesLeaf > 0) && cfg.Epsilon > 0:
+		return cfg, fmt.Errorf("minimum sample sizes are not supported for private trees")
+	case cfg.LeafSamples < 0:
+		return cfg, fmt.Errorf("leaf samples must not be negative, got %d", cfg.LeafSamples)
+	case cfg.LeafSamples > 0 && cfg.Epsilon > 0:
+		return cfg, fmt.Errorf("leaf samples would store training rows in a private model")
+	case len(cfg.InteractionGroups) > 0 && cfg.Epsilon > 0:
+		return cfg, fmt.Errorf("interaction groups are not supported for private trees")
+	case len(cfg.FeaturePenalties) > 0 && cfg.Epsilon > 0:
+		return cfg, fmt.Errorf("feature penalties are not supported for private trees")
+	case cfg.Honest && cfg.Epsilon > 0:
+		return cfg, fmt.Errorf("honest training is not supported for private trees")
+	}
+	for feature, multiplier := range cfg.FeaturePenalties {
+		if multiplier <= 0 {
+			return cfg, fmt.Errorf("penalty for %s must be positive, got %v", feature, multiplier)
+		}
+	}
+	switch cfg.Criterion {
+	case InfoGainRatio, InfoGain, Gini:
+	default:
+		return cfg, fmt.Errorf("unknown split criterion %q (use %s, %s or %s)", cfg.Criterion, InfoGainRatio, InfoGain, Gini)
+	}
+	return cfg, nil
+}
+
+// Train grows a decision tree on a loaded dataset whose last column is the target, e.g.
+//
+//	tree, err := Train(header, dataset, WithMaxDepth(5), WithCriterion(Gini), WithSeed(1))
+//
+// Without options it grows the same tree as BuildDecisionTree.
+func Train(header []string, dataset [][]interface{}, opts ...Option) (*TreeNode, error) {
+	cfg, err := NewTrainConfig(opts...)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	if len(dataset) == 0 {
+		return nil, fmt.Errorf("no training rows")
+	}
+
+	if err := checkInteractionGroups(cfg.InteractionGroups, header); err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	for feature := range cfg.FeaturePenalties {
+		if col := findColumn(header, feature); col == -1 || col == len(header)-1 {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("penalty given for unknown feature %q", feature))
+		}
+	}
+	// Private trees split only on the declared domains: excluding columns because of what
+	// the training rows hold would make the candidate splits depend on the data
+	if !cfg.KeepAllColumns && cfg.Epsilon == 0 {
+		cfg.excluded = unusableColumns(header, dataset)
+	}
+
+	grow := dataset
+	var estimation [][]interface{}
+	if cfg.Honest {
+		if len(dataset) < 4 {
+			return nil, fmt.Errorf("honest training needs at least 4 rows, got %d", len(dataset))
+		}
+		grow, estimation = honestSplit(dataset, cfg.Seed)
+	}
+
+	var tree *TreeNode
+	switch {
+	case cfg.Epsilon > 0:
+		depth := cfg.MaxDepth
+		if depth == 0 {
+			depth = defaultPrivateDepth
+		}
+		return BuildDecisionTreeDP(dataset, header, DPOptions{Epsilon: cfg.Epsilon, MaxDepth: depth, Seed: cfg.Seed, Domains: cfg.Domains})
+	case cfg.MaxLeaves > 0:
+		tree = buildBestFirst(grow, header, cfg)
+	default:
+		tree = buildDecisionTree(grow, header, cfg, nil)
+	}
+	if cfg.Honest {
+		reestimate(tree, header, estimation)
+	}
+	if cfg.LeafSamples > 0 {
+		retainLeafSamples(tree, header, dataset, cfg.LeafSamples, cfg.Seed)
+	}
+	if len(cfg.excluded) > 0 {
+		tree.Skipped = cfg.excluded
+	}
+	return tree, nil
+}
+
+// usable reports whether a split on attribute may follow splits on the attributes in path
+func (c TrainConfig) usable(attribute string, path []string) bool {
+	if c.excluded[attribute] != "" {
+		return false
+	}
+	return len(c.InteractionGroups) == 0 || allowedTogether(c.InteractionGroups, extendPath(path, attribute))
+}
+
+// surrogateUsable reports which features may stand in for a split made after path. A
+// surrogate would meet the features split on below it, which may share no group with it, so
+// trees grown under interaction groups keep none and route missing values by default branch.
+func (c TrainConfig) surrogateUsable(path []string) func(string) bool {
+	return func(attribute string) bool { return len(c.InteractionGroups) == 0 && c.usable(attribute, path) }
+}
+
+// unusableColumns finds the feature columns no split should use and why: constant
+// columns, which cannot separate rows, and categorical columns with a different value in
+// every row, such as IDs, which separate the training rows perfectly and predict nothing.
+// Numeric columns are never ID-like, since continuous measurements are often all distinct.
+func unusableColumns(header []string, dataset [][]interface{}) map[string]string {
+	excluded := make(map[string]string)
+	for col, name := range header[:len(header)-1] {
+		distinct := make(map[interface{}]bool)
+		categorical := false
+		for _, row := range dataset {
+			if row[col] == nil || row[col] == "" {
+				continue
+			}
+			_, isString := row[col].(string)
+			categorical = categorical || isString
+			distinct[row[col]] = true
+		}
+
+		reason := ""
+		switch {
+		case len(distinct) <= 1:
+			reason = "it has a single value"
+		case categorical && len(dataset) > 2 && len(distinct) == len(dataset):
+			reason = "every row has a different value, like an ID"
+		}
+		if reason != "" {
+			excluded[name] = reason
+		}
+	}
+	return excluded
+}
+
+// leavesLargeEnough reports whether every subset of a candidate split keeps at least
+// MinSamplesLeaf rows
+func (c TrainConfig) leavesLargeEnough(subsets map[string][][]interface{}) bool {
+	for _, subset := range subsets {
+		if len(subset) < c.MinSamplesLeaf {
+			return false
+		}
+	}
+	return true
+}
+
+// penalty returns the multiplier applied to a feature's split scores
+func (c TrainConfig) penalty(attribute string) float64 {
+	if multiplier, ok := c.FeaturePenalties[attribute]; ok {
+		return multiplier
+	}
+	return 1
+}
+
+// ParseFeaturePenalties reads multipliers written as "Cost=0.5,Sensor=0.8"
+func ParseFeaturePenalties(spec string) ([]Option, error) {
+	var opts []Option
+	for _, part := range strings.Split(spec, ",") {
+		feature, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || feature == "" {
+			return nil, fmt.Errorf("feature penalty expects feature=multiplier, got %q", part)
+		}
+		multiplier, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid penalty for %s: %v", feature, err)
+		}
+		opts = append(opts, WithFeaturePenalty(strings.TrimSpace(feature), multiplier))
+	}
+	return opts, nil
+}
+
+// splitScore rates splitting on attribute under a criterion; higher is better
+func splitScore(criterion Criterion, dataset [][]interface{}, header []string, attribute string, idx *ValueIndex) float64 {
+	switch criterion {
+	case InfoGain:
+		return informationGain(dataset, header, attribute, idx)
+	case Gini:
+		return giniGain(dataset, header, attribute, idx)
+	}
+	return gainRatio(dataset, header, attribute, idx)
+}
+
+// impurityDecrease is the drop in impurity a split achieves: Gini gain under Gini and
+// information gain otherwise
+func impurityDecrease(criterion Criterion, dataset [][]interface{}, header []string, attribute string, idx *ValueIndex) float64 {
+	if criterion == Gini {
+		return giniGain(dataset, header, attribute, idx)
+	}
+	return informationGain(dataset, header, attribute, idx)
+}
+
+// GiniImpurity is the chance that two rows drawn at random from the dataset belong to
+// different classes
+func GiniImpurity(dataset [][]interface{}) float64 {
+	if len(dataset) == 0 {
+		return 0
+	}
+	impurity := 1.0
+	for _, p := range ComputeProbabilities(CountClassOccurrences(dataset), len(dataset)) {
+		impurity -= p * p
+	}
+	return impurity
+}
+
+// giniGain is the reduction in Gini impurity achieved by splitting on attribute
+func giniGain(dataset [][]interface{}, header []string, attribute string, idx *ValueIndex) float64 {
+	if len(dataset) == 0 {
+		return 0
+	}
+	weighted := 0.0
+	for _, subset := range splitDataset(dataset, header, attribute, idx) {
+		weighted += float64(len(subset)) / float64(len(dataset)) * GiniImpurity(subset)
+	}
+	return GiniImpurity(dataset) - weighted
+}
@@ -0,0 +1,665 @@
+// Package dtree trains decision trees on CSV or in-memory data, saves and loads them as
+// model files, and predicts, evaluates and explains with them. The dt command in hacker2 is
+// a thin command-line wrapper around it, and the hacker3 and loadingcsv demos print its
+// entropy, gain ratio and tree building steps. generic keeps its own string-only trees and
+// model format, and hacker does not build.
+package dtree
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+	"math"
+	"encoding/json"
+
+	"machineLearning/metrics"
+)
+
+// LoadCsv loads a CSV file and detects data types (categorical, numeric, date).
+// With a cache directory configured, parsed datasets are reused across runs.
+func (l *Loader) LoadCsv(filename string) ([]string, [][]interface{}, []string, error) {
+	return l.loadCsv(filename, l.opts)
+}
+
+// loadCsv loads a CSV file with the given options, which may differ from the loader's when
+// a model's training settings apply
+func (l *Loader) loadCsv(filename string, opts LoadOptions) ([]string, [][]interface{}, []string, error) {
+	if opts.CacheDir != "" {
+		return l.loadCachedCsv(filename, opts)
+	}
+	return l.parseCsv(filename, opts)
+}
+
+// parseCsv reads and converts a CSV file
+func (l *Loader) parseCsv(filename string, opts LoadOptions) ([]string, [][]interface{}, []string, error) {
+	records, release, err := l.readRecords(filename, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer release()
+
+	if len(records) < 2 {
+		return nil, nil, nil, withExitCode(ExitParse, fmt.Errorf("insufficient data in CSV file"))
+	}
+
+	header := cloneStrings(records[0])
+	for i := range header {
+		header[i] = composeNFC(header[i])
+	}
+	if err := renameColumns(header, opts); err != nil {
+		return nil, nil, nil, err
+	}
+	rawData := records[1:]
+
+	// Infer column types from a sample and convert values in a single pass, sharded across columns
+	dataset, colTypes, reports := convertColumns(header, rawData, opts)
+	if opts.Strict {
+		if err := checkStrict(header, rawData, reports, opts); err != nil {
+			return nil, nil, nil, withExitCode(ExitParse, fmt.Errorf("strict mode: %v", err))
+		}
+	}
+	l.reportColumnTypes(header, reports, opts)
+
+	if opts.Filter != "" {
+		dataset, err = FilterRows(header, dataset, opts.Filter)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return header, dataset, colTypes, nil
+}
+
+// parseDate tries to parse a string into a time.Time object
+func parseDate(value string) (time.Time, error) {
+	formats := []string{"2006-01-02", "02/01/2006", "01-02-2006", "2006/01/02"}
+	for _, format := range formats {
+		t, err := time.Parse(format, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date format: %s", value)
+}
+
+
+// CountClassOccurrences counts occurrences of each target class in the dataset
+func CountClassOccurrences(dataset [][]interface{}) map[string]int {
+	classCounts := make(map[string]int)
+
+	for _, row := range dataset {
+		if len(row) == 0 {
+			continue
+		}
+		targetClass, ok := row[len(row)-1].(string) // Ensure it's categorical
+		if !ok {
+			continue // Skip if it's not a string (categorical class)
+		}
+		classCounts[targetClass]++
+	}
+
+	return classCounts
+}
+
+
+// ComputeProbabilities calculates the probability of each class in the dataset
+func ComputeProbabilities(classCounts map[string]int, totalSamples int) map[string]float64 {
+	probabilities := make(map[string]float64)
+
+	for class, count := range classCounts {
+		probabilities[class] = float64(count) / float64(totalSamples)
+	}
+	return probabilities
+}
+
+
+// Entropy calculates the entropy of the dataset (impurity measure)
+func Entropy(dataset [][]interface{}) float64 {
+	countClassOccurrences := CountClassOccurrences(dataset)
+	totalSamples := len(dataset)
+	if totalSamples == 0 {
+		return 0.0
+	}
+
+	probabilities := ComputeProbabilities(countClassOccurrences, totalSamples)
+	entropy := 0.0
+
+	for _, probability := range probabilities {
+		if probability > 0 {
+			entropy -= probability * math.Log2(probability)
+		}
+	}
+	return entropy
+}
+
+
+// SplitDataset handles both categorical and numerical attributes
+func SplitDataset(dataset [][]interface{}, header []string, attribute string) map[string][][]interface{} {
+	return splitDataset(dataset, header, attribute, nil)
+}
+
+// splitDataset splits like SplitDataset, taking categorical subsets from idx when it has them
+func splitDataset(dataset [][]interface{}, header []string, attribute string, idx *ValueIndex) map[string][][]interface{} {
+	subsets := make(map[string][][]interface{})
+
+	attrIndex := -1
+	for i, col := range header {
+		if col == attribute {
+			attrIndex = i
+			break
+		}
+	}
+
+	if attrIndex == -1 {
+		fmt.Println("Error: Attribute not found in header")
+		return subsets
+	}
+
+	// Check the type of the attribute (categorical or numerical)
+	switch dataset[0][attrIndex].(type) {
+	case string:
+		// Categorical split, by lookup when the column is indexed
+		if indexed, ok := idx.Split(attrIndex); ok {
+			return indexed
+		}
+		for _, row := range dataset {
+			if attrIndex < len(row) {
+				key, _ := row[attrIndex].(string)
+				subsets[key] = append(subsets[key], row)
+			}
+		}
+	default:
+		// Numeric or date split (find best threshold)
+		bestThreshold, leftSubset, rightSubset := FindBestThreshold(dataset, attrIndex)
+		subsets[fmt.Sprintf("<=%.2f", bestThreshold)] = leftSubset
+		subsets[fmt.Sprintf(">%.2f", bestThreshold)] = rightSubset
+	}
+
+	return subsets
+}
+
+// FindBestThreshold finds the best threshold to split a numeric attribute. Rows missing the
+// value join the side learned for them (see findThreshold).
+func FindBestThreshold(dataset [][]interface{}, attrIndex int) (float64, [][]interface{}, [][]interface{}) {
+	s := findThreshold(dataset, attrIndex)
+	return s.threshold, s.left, s.right
+}
+
+// InformationGain calculates how much information is gained by splitting on an attribute
+func InformationGain(dataset [][]interface{}, header []string, attribute string) float64 {
+	return informationGain(dataset, header, attribute, nil)
+}
+
+func informationGain(dataset [][]interface{}, header []string, attribute string, idx *ValueIndex) float64 {
+	totalSamples := len(dataset)
+	if totalSamples == 0 {
+		return 0
+	}
+
+	initialEntropy := Entropy(dataset)
+	splitted := splitDataset(dataset, header, attribute, idx)
+
+	weightedEntropy := 0.0
+	for _, subset := range splitted {
+		proportion := float64(len(subset)) / float64(totalSamples)
+		weightedEntropy += proportion * Entropy(subset)
+	}
+
+	informationGain := initialEntropy - weightedEntropy
+	return informationGain
+}
+
+// GainRatio calculates the gain ratio, a normalized version of information gain
+func GainRatio(dataset [][]interface{}, header []string, attribute string) float64 {
+	return gainRatio(dataset, header, attribute, nil)
+}
+
+func gainRatio(dataset [][]interface{}, header []string, attribute string, idx *ValueIndex) float64 {
+	totalSamples := len(dataset)
+	if totalSamples == 0 {
+		return 0
+	}
+
+	infoGain := informationGain(dataset, header, attribute, idx)
+	if infoGain == 0 {
+		return 0
+	}
+
+	splitted := splitDataset(dataset, header, attribute, idx)
+
+	splitInfo := 0.0
+	for _, subset := range splitted {
+		proportion := float64(len(subset)) / float64(totalSamples)
+		if proportion > 0 {
+			splitInfo -= proportion * math.Log2(proportion)
+		}
+	}
+
+	if splitInfo == 0 {
+		return 0
+	}
+
+	gainRatio := infoGain / splitInfo
+	return gainRatio
+}
+
+// BestAttribute finds the attribute with the highest Gain Ratio and returns it.
+func BestAttribute(dataset [][]interface{}, header []string) string {
+	return bestAttribute(dataset, header, IndexDataset(dataset), TrainConfig{Criterion: InfoGainRatio}, nil)
+}
+
+// bestAttribute finds the attribute scoring highest under the configured criterion among
+// those the configuration lets follow the attributes already split on along path
+func bestAttribute(dataset [][]interface{}, header []string, idx *ValueIndex, cfg TrainConfig, path []string) string {
+	bestAttr := ""
+	bestGainRatio := -1.0
+
+	for _, attr := range header[:len(header)-1] { // Exclude target variable
+		if !cfg.usable(attr, path) {
+			continue
+		}
+		if cfg.MinSamplesLeaf > 0 && !cfg.leavesLargeEnough(splitDataset(dataset, header, attr, idx)) {
+			continue
+		}
+		ratio := splitScore(cfg.Criterion, dataset, header, attr, idx) * cfg.penalty(attr)
+
+		if ratio > bestGainRatio {
+			bestGainRatio = ratio
+			bestAttr = attr
+		}
+	}
+
+	return bestAttr
+}
+
+type TreeNode struct {
+	Attribute  string
+	Threshold  float64
+	Children   map[string]*TreeNode
+	// MissingBranch is the child key that rows missing a numeric split's value follow, learned
+	// from the training rows that lacked it; empty when there were none
+	MissingBranch string `json:"MissingBranch,omitempty"`
+	// Samples holds up to TrainConfig.LeafSamples training rows that reach a leaf, laid out
+	// as the root's SampleColumns
+	Samples       [][]string `json:"Samples,omitempty"`
+	SampleColumns []string   `json:"SampleColumns,omitempty"`
+	// Surrogates are splits on other features that mimic this one, best first, for routing
+	// rows missing the split's feature
+	Surrogates []Surrogate `json:"Surrogates,omitempty"`
+	Class      string
+	IsLeaf     bool
+	// ClassCounts holds the training rows of each class that reached the node
+	ClassCounts map[string]int `json:"ClassCounts,omitempty"`
+	// Derive holds the derived-column expressions the model was trained with (root only),
+	// so prediction can compute the same columns
+	Derive []string `json:"Derive,omitempty"`
+	// NATokens holds the missing-value tokens the training data was loaded with (root only)
+	NATokens []string `json:"NATokens,omitempty"`
+	// Normalize holds the categorical normalization the training data was loaded with (root only)
+	Normalize *Normalization `json:"Normalize,omitempty"`
+	// Aliases maps the original names of columns renamed for training to the names the
+	// model uses (root only), so files with the original names still score
+	Aliases map[string]string `json:"Aliases,omitempty"`
+	// Skipped holds the constant and ID-like feature columns training did not split on, with
+	// the reason (root only)
+	Skipped map[string]string `json:"Skipped,omitempty"`
+	// Members holds the trees of a voting ensemble built by MergeModels (root only); an
+	// ensemble predicts the majority vote of its members
+	Members []*TreeNode `json:"Members,omitempty"`
+}
+
+// BuildDecisionTree constructs a decision tree based on the dataset.
+func BuildDecisionTree(dataset [][]interface{}, header []string) *TreeNode {
+	return buildDecisionTree(dataset, header, TrainConfig{Criterion: InfoGainRatio}, nil)
+}
+
+// buildDecisionTree grows the subtree for a node reached by splitting on the attributes in
+// path, one per level
+func buildDecisionTree(dataset [][]interface{}, header []string, cfg TrainConfig, path []string) *TreeNode {
+	classCounts := CountClassOccurrences(dataset)
+
+	// If all samples belong to the same class, return a leaf node
+	if len(classCounts) == 1 {
+		for class := range classCounts {
+			return &TreeNode{Class: class, IsLeaf: true, ClassCounts: classCounts}
+		}
+	}
+	if (cfg.MaxDepth > 0 && len(path) >= cfg.MaxDepth) || len(dataset) < cfg.MinSamplesSplit {
+		return &TreeNode{Class: majorityClass(classCounts), IsLeaf: true, ClassCounts: classCounts}
+	}
+
+	// Index the categorical columns once per node; every candidate split reuses it
+	idx := IndexDataset(dataset)
+	bestAttr := bestAttribute(dataset, header, idx, cfg, path)
+	if bestAttr == "" {
+		// If no good split is found, return the most common class
+		mostCommonClass := ""
+		maxCount := 0
+		for class, count := range classCounts {
+			if count > maxCount {
+				maxCount = count
+				mostCommonClass = class
+			}
+		}
+		return &TreeNode{Class: mostCommonClass, IsLeaf: true, ClassCounts: classCounts}
+	}
+
+	attrIndex := -1
+	for i, col := range header {
+		if col == bestAttr {
+			attrIndex = i
+			break
+		}
+	}
+
+	node := &TreeNode{Attribute: bestAttr, Children: make(map[string]*TreeNode), ClassCounts: classCounts}
+
+	// Determine whether the attribute is numeric or categorical
+	switch dataset[0][attrIndex].(type) {
+	case string:
+		// Categorical split
+		splitted := splitDataset(dataset, header, bestAttr, idx)
+		if len(splitted) < 2 {
+			// Every row has the same value, so splitting would recurse forever
+			return &TreeNode{Class: majorityClass(classCounts), IsLeaf: true, ClassCounts: classCounts}
+		}
+		node.Surrogates = findSurrogates(splitted, header, attrIndex, cfg.surrogateUsable(path))
+		for attrValue, subset := range splitted {
+			node.Children[attrValue] = buildDecisionTree(subset, header, cfg, extendPath(path, bestAttr))
+		}
+	default:
+		// Numeric split (find threshold)
+		split := findThreshold(dataset, attrIndex)
+		if len(split.left) == 0 || len(split.right) == 0 {
+			return &TreeNode{Class: majorityClass(classCounts), IsLeaf: true, ClassCounts: classCounts}
+		}
+		node.Threshold = split.threshold
+		node.MissingBranch = split.missingBranch()
+		node.Surrogates = findSurrogates(map[string][][]interface{}{
+			fmt.Sprintf("<=%.2f", split.threshold): split.left,
+			fmt.Sprintf(">%.2f", split.threshold):  split.right,
+		}, header, attrIndex, cfg.surrogateUsable(path))
+		node.Children[fmt.Sprintf("<=%.2f", split.threshold)] = buildDecisionTree(split.left, header, cfg, extendPath(path, bestAttr))
+		node.Children[fmt.Sprintf(">%.2f", split.threshold)] = buildDecisionTree(split.right, header, cfg, extendPath(path, bestAttr))
+	}
+
+	return node
+}
+
+// Train decision tree and save model.
+// The tree is grown by Train with the given options. Derived columns
+// are computed before training and recorded in the model. With several target columns
+// the CSV is loaded once and one model per target is saved (see TrainTargets). A positive
+// minAccuracy fails training, without saving, when the training accuracy falls below it.
+func (l *Loader) TrainModel(inputFile string, targetCols []string, outputFile string, opts []Option, derive []string, minAccuracy float64) ([]*metrics.Report, error) {
+	// Load dataset
+	header, dataset, _, err := l.loadCsv(inputFile, l.opts.withLabels(targetCols...)) // Ignoring colTypes
+	if err != nil {
+		return nil, err
+	}
+	header, dataset, err = DeriveColumns(header, dataset, derive, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(targetCols) > 1 {
+		return l.TrainTargets(header, dataset, targetCols, outputFile, opts, derive, minAccuracy)
+	}
+	header, dataset, err = targetView(header, dataset, targetCols[0], targetCols)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+
+	// Train decision tree
+	tree, err := Train(header, dataset, opts...)
+	if err != nil {
+		return nil, withDefaultExitCode(ExitTraining, err)
+	}
+	tree.Derive = derive
+	recordLoadOptions(tree, l.opts)
+	l.warnSkipped(tree)
+
+	report, err := trainingReport(tree, header, dataset)
+	if err != nil {
+		return nil, err
+	}
+	if minAccuracy > 0 {
+		fmt.Printf("Training accuracy: %.4f (minimum %.4f)\n", report.Metrics["accuracy"], minAccuracy)
+	}
+	if err := CheckAccuracy(header[len(header)-1], report, minAccuracy); err != nil {
+		return nil, err
+	}
+
+	if err := l.SaveModel(tree, outputFile); err != nil {
+		return nil, err
+	}
+	fmt.Println("Model saved to", outputFile)
+	return []*metrics.Report{report}, nil
+}
+
+// trainingReport scores a tree on the data it was trained on, whose last column is the target
+func trainingReport(tree *TreeNode, header []string, dataset [][]interface{}) (*metrics.Report, error) {
+	targetIndex := len(header) - 1
+	actual := make([]string, len(dataset))
+	predicted := make([]string, len(dataset))
+	for r, row := range dataset {
+		actual[r] = fmt.Sprintf("%v", row[targetIndex])
+		predicted[r] = Predict(tree, rowInstance(header, row, targetIndex))
+	}
+	return metrics.Classification(actual, predicted)
+}
+
+// SaveModel writes a tree to a model file, encrypted when the loader has a model key
+func (l *Loader) SaveModel(tree *TreeNode, outputFile string) error {
+	// Save model as JSON, encrypted when a model key is configured
+	payload, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("Error encoding model: %v", err)
+	}
+	payload = append(payload, '\n')
+	if l.key != nil {
+		payload, err = encryptModel(l.key, payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	modelFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("Error creating model file: %v", err)
+	}
+	defer modelFile.Close()
+
+	_, err = modelFile.Write(payload)
+	if err != nil {
+		return fmt.Errorf("Error writing model: %v", err)
+	}
+	return nil
+}
+
+// Load model from JSON file, decrypting it first if it was saved encrypted
+func (l *Loader) LoadModel(modelFile string) (*TreeNode, error) {
+	data, err := os.ReadFile(modelFile)
+	if err != nil {
+		return nil, openError("Error opening model file", err)
+	}
+	payload, err := decryptModel(l.key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree TreeNode
+	err = json.Unmarshal(payload, &tree)
+	if err != nil {
+		return nil, withExitCode(ExitParse, fmt.Errorf("Error decoding model file: %v", err))
+	}
+
+	return &tree, nil
+}
+
+// Predict a single instance
+func Predict(node *TreeNode, instance map[string]string) string {
+	if len(node.Members) > 0 {
+		return vote(node, instance)
+	}
+	if node.IsLeaf {
+		return node.Class
+	}
+
+	// Navigate the tree, routing a missing value by surrogate or default branch
+	if child := childFor(node, instance); child != nil {
+		return Predict(child, instance)
+	}
+	if _, exists := instance[node.Attribute]; !exists {
+		return "Unknown"
+	}
+
+	// Fallback: If unseen value, return majority class
+	return FindMostCommonClass(node)
+}
+
+// nextNode returns the child an attribute value leads to, or nil if the value is unseen
+func nextNode(node *TreeNode, attrValue string) *TreeNode {
+	if child, found := node.Children[attrValue]; found {
+		return child
+	}
+
+	// Numeric splits store their children under "<=threshold" and ">threshold"
+	left, isNumeric := node.Children[fmt.Sprintf("<=%.2f", node.Threshold)]
+	right := node.Children[fmt.Sprintf(">%.2f", node.Threshold)]
+	if value, err := strconv.ParseFloat(attrValue, 64); isNumeric && right != nil && len(node.Children) == 2 && err == nil {
+		if value <= node.Threshold {
+			return left
+		}
+		return right
+	}
+	return nil
+}
+
+func FindMostCommonClass(node *TreeNode) string {
+	classCount := make(map[string]int)
+
+	for _, child := range node.Children {
+		if child.IsLeaf {
+			classCount[child.Class]++
+		} else {
+			classCount[FindMostCommonClass(child)]++
+		}
+	}
+
+	// Find most frequent class, breaking ties alphabetically so the result is stable
+	var mostCommonClass string
+	maxCount := 0
+	for class, count := range classCount {
+		if count > maxCount || (count == maxCount && class < mostCommonClass) {
+			mostCommonClass = class
+			maxCount = count
+		}
+	}
+	return mostCommonClass
+}
+
+
+// Predict from test CSV using trained model. With contributions set, each row also gets
+// the bias and per-feature contributions behind its prediction (see Contributions); with
+// summary set, a PredictionSummary of the run is printed at the end. With costs set, each row
+// gets the class of lowest expected cost rather than the most likely one. Rows the model is
+// less sure of than abstain.MinConfidence get abstain.Label instead of a prediction.
+func (l *Loader) PredictFromModel(inputFile, modelFile, outputFile string, contributions, summary bool, costs CostMatrix, abstain Abstention) error {
+	// Load model
+	tree, err := l.LoadModel(modelFile)
+	if err != nil {
+		return err
+	}
+
+	// Load dataset the way the model's training data was loaded, except that the values are
+	// written out as given: normalization only applies to what the model sees
+	opts := l.modelOptions(tree)
+	normalize := opts.Normalize
+	opts.Normalize = Normalization{}
+	header, written, _, err := l.loadCsv(inputFile, opts) // Ignoring colTypes
+	if err != nil {
+		return err
+	}
+	dataset := written
+	if normalize.Enabled() {
+		dataset = normalizeRows(written, normalize)
+	}
+	header, dataset, err = DeriveColumns(header, dataset, tree.Derive, false)
+	if err != nil {
+		return err
+	}
+
+	// Open output file
+	outFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("Error creating output file: %v", err)
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	defer writer.Flush()
+
+	// Write header with "Prediction" column
+	newHeader := append(append([]string{}, header...), "Prediction")
+	if contributions {
+		newHeader = append(newHeader, "Bias")
+		for _, col := range header {
+			newHeader = append(newHeader, "C_"+col)
+		}
+	}
+	writer.Write(newHeader)
+
+	// Flatten the tree once and predict each row against its column positions
+	predict := rowPredictor(tree, header)
+	report := NewPredictionSummary()
+	abstained := 0
+	for r, row := range dataset {
+		values := interfaceSliceToStringSlice(row)
+		prediction := predict(values)
+		if costs != nil {
+			prediction, _ = decideByCost(tree, rowInstance(header, row, -1), costs, prediction)
+		}
+		if abstain.rejects(Probabilities(tree, rowInstance(header, row, -1))) {
+			prediction = abstain.Label
+			abstained++
+		}
+		// Derived columns follow the input's, so only those come from the normalized row
+		newRow := append(interfaceSliceToStringSlice(written[r]), values[len(written[r]):]...)
+		newRow = append(newRow, prediction)
+		if contributions {
+			_, bias, contrib, err := Contributions(tree, rowInstance(header, row, -1))
+			if err != nil {
+				return err
+			}
+			newRow = append(newRow, strconv.FormatFloat(bias, 'f', 4, 64))
+			for _, col := range header {
+				newRow = append(newRow, strconv.FormatFloat(contrib[col], 'f', 4, 64))
+			}
+		}
+		writer.Write(newRow)
+		if summary {
+			report.Add(prediction, Probabilities(tree, rowInstance(header, row, -1)))
+		}
+	}
+	fmt.Println("Predictions saved to", outputFile)
+	printAbstention(abstain, abstained, len(dataset))
+	if summary {
+		report.Print()
+	}
+	return nil
+}
+
+// Convert interface{} slice to string slice
+func interfaceSliceToStringSlice(row []interface{}) []string {
+	result := make([]string, len(row))
+	for i, val := range row {
+		if val == nil {
+			continue // Missing values are written as empty cells
+		}
+		result[i] = fmt.Sprintf("%v", val)
+	}
+	return result
+}
+
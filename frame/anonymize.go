@@ -0,0 +1,80 @@
+package frame
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// saltSize is the length in bytes of a generated salt
+const saltSize = 32
+
+// LoadSalt reads a hex-encoded salt from path, creating the file with a fresh random salt
+// if it does not exist yet. Keep the salt apart from the exported data: anyone holding both
+// can test guesses of the original values.
+func LoadSalt(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("error generating salt: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(salt)+"\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("error writing salt file: %v", err)
+		}
+		return salt, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading salt file: %v", err)
+	}
+
+	salt, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding salt file %s: %v", path, err)
+	}
+	if len(salt) == 0 {
+		return nil, fmt.Errorf("salt file %s is empty", path)
+	}
+	return salt, nil
+}
+
+// Anonymize replaces every value of the named columns with a keyed hash token. The same
+// value and salt always give the same token, in any column or file, so anonymized columns
+// still work as join keys and categories. Missing values stay missing and the columns
+// become categorical.
+func (f *Frame) Anonymize(salt []byte, cols ...string) (*Frame, error) {
+	if len(salt) == 0 {
+		return nil, fmt.Errorf("anonymizing needs a non-empty salt")
+	}
+	for _, name := range cols {
+		if _, ok := f.index[name]; !ok {
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+	}
+
+	out := f
+	for _, name := range cols {
+		out = out.Mutate(name, func(r Row) interface{} {
+			v := r.Get(name)
+			if v == nil {
+				return nil
+			}
+			return Token(salt, formatCell(v))
+		})
+	}
+	return out, nil
+}
+
+// Token returns the keyed hash token of one value: the first 16 hex digits of its
+// HMAC-SHA256 under the salt
+func Token(salt []byte, value string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(value))
+	return "tok_" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -0,0 +1,129 @@
+package frame
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ReadCSV loads a CSV file into a frame, detecting numeric, date and categorical columns
+// the same way the decision tree loader does
+func ReadCSV(filename string) (*Frame, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("insufficient data in CSV file")
+	}
+
+	header := records[0]
+	rawData := records[1:]
+	types := detectColumnTypes(rawData)
+
+	rows := make([][]interface{}, len(rawData))
+	for i, record := range rawData {
+		rows[i] = make([]interface{}, len(record))
+		for j, val := range record {
+			switch types[j] {
+			case "numeric":
+				rows[i][j], _ = strconv.ParseFloat(val, 64)
+			case "date":
+				rows[i][j], _ = parseDate(val)
+			default:
+				rows[i][j] = val
+			}
+		}
+	}
+	return newFrame(header, types, rows)
+}
+
+// FromRecords wraps rows that were already converted by a loader together with their column types
+func FromRecords(header []string, rows [][]interface{}, colTypes []string) (*Frame, error) {
+	if len(colTypes) != len(header) {
+		return nil, fmt.Errorf("got %d column types for %d columns", len(colTypes), len(header))
+	}
+	for i, row := range rows {
+		if len(row) != len(header) {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(header))
+		}
+	}
+	return newFrame(header, colTypes, rows)
+}
+
+// detectColumnTypes determines if each column is categorical, numeric, or a date
+func detectColumnTypes(data [][]string) []string {
+	colTypes := make([]string, len(data[0]))
+	for col := range colTypes {
+		isNumeric, isDate := true, true
+		for _, row := range data {
+			if _, err := strconv.ParseFloat(row[col], 64); err != nil {
+				isNumeric = false
+			}
+			if _, err := parseDate(row[col]); err != nil {
+				isDate = false
+			}
+		}
+
+		if isNumeric {
+			colTypes[col] = "numeric"
+		} else if isDate {
+			colTypes[col] = "date"
+		} else {
+			colTypes[col] = "categorical"
+		}
+	}
+	return colTypes
+}
+
+// parseDate tries to parse a string into a time.Time object
+func parseDate(value string) (time.Time, error) {
+	formats := []string{"2006-01-02", "02/01/2006", "01-02-2006", "2006/01/02"}
+	for _, format := range formats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date format: %s", value)
+}
+
+// WriteCSV saves the frame as a CSV file with a header row. Dates are written as
+// YYYY-MM-DD and missing values as empty cells, so the file loads back with the same types.
+func (f *Frame) WriteCSV(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write(f.header)
+	record := make([]string, len(f.header))
+	for _, row := range f.rows {
+		for j, v := range row {
+			record[j] = formatCell(v)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing output file: %v", err)
+	}
+	return nil
+}
+
+// formatCell renders a value for CSV output without losing numeric precision
+func formatCell(v interface{}) string {
+	if num, ok := v.(float64); ok {
+		return strconv.FormatFloat(num, 'f', -1, 64)
+	}
+	return formatValue(v)
+}
@@ -0,0 +1,322 @@
+// Package frame provides a column-aware table for feature engineering in Go before
+// training. Values use the same representation as the CSV loaders: float64 for numeric
+// columns, time.Time for dates and string for categorical columns.
+package frame
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Frame is a table of typed columns. Column operations return a new Frame; only Set
+// and Append modify a frame in place.
+type Frame struct {
+	header []string
+	types  []string
+	index  map[string]int
+	rows   [][]interface{}
+	target string
+	weight string
+
+	mu    sync.Mutex
+	stats map[int]*ColumnStats
+}
+
+// New builds a frame from a header and rows of converted values. Column types are
+// taken from the first non-nil value of each column.
+func New(header []string, rows [][]interface{}) (*Frame, error) {
+	for i, row := range rows {
+		if len(row) != len(header) {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(header))
+		}
+	}
+
+	types := make([]string, len(header))
+	for col := range header {
+		types[col] = "categorical"
+		for _, row := range rows {
+			if row[col] != nil {
+				types[col] = valueType(row[col])
+				break
+			}
+		}
+	}
+	return newFrame(header, types, rows)
+}
+
+func newFrame(header, types []string, rows [][]interface{}) (*Frame, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		index[name] = i
+	}
+	return &Frame{header: header, types: types, index: index, rows: rows}, nil
+}
+
+// valueType classifies a converted value as numeric, date or categorical
+func valueType(v interface{}) string {
+	switch v.(type) {
+	case float64, int:
+		return "numeric"
+	case time.Time:
+		return "date"
+	}
+	return "categorical"
+}
+
+// Columns returns the column names in order
+func (f *Frame) Columns() []string {
+	return append([]string{}, f.header...)
+}
+
+// Types returns the type of every column: numeric, date or categorical
+func (f *Frame) Types() []string {
+	return append([]string{}, f.types...)
+}
+
+// Len returns the number of rows
+func (f *Frame) Len() int {
+	return len(f.rows)
+}
+
+// Records returns the header and rows in the [][]interface{} layout used by the tree code
+func (f *Frame) Records() ([]string, [][]interface{}) {
+	return f.Columns(), f.rows
+}
+
+// Row returns a view of row i
+func (f *Frame) Row(i int) Row {
+	return Row{frame: f, values: f.rows[i]}
+}
+
+// Rows yields a view of every row in order without copying them; breaking out of the loop
+// stops the walk
+func (f *Frame) Rows() iter.Seq[Row] {
+	return func(yield func(Row) bool) {
+		for _, row := range f.rows {
+			if !yield(Row{frame: f, values: row}) {
+				return
+			}
+		}
+	}
+}
+
+// Column returns every value of one column
+func (f *Frame) Column(name string) ([]interface{}, error) {
+	col, ok := f.index[name]
+	if !ok {
+		return nil, fmt.Errorf("column %q not found", name)
+	}
+	values := make([]interface{}, len(f.rows))
+	for i, row := range f.rows {
+		values[i] = row[col]
+	}
+	return values, nil
+}
+
+// Select keeps only the named columns, in the order given
+func (f *Frame) Select(cols ...string) (*Frame, error) {
+	positions := make([]int, len(cols))
+	types := make([]string, len(cols))
+	for i, name := range cols {
+		col, ok := f.index[name]
+		if !ok {
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+		positions[i] = col
+		types[i] = f.types[col]
+	}
+
+	rows := make([][]interface{}, len(f.rows))
+	for i, row := range f.rows {
+		rows[i] = make([]interface{}, len(cols))
+		for j, col := range positions {
+			rows[i][j] = row[col]
+		}
+	}
+	out, err := newFrame(append([]string{}, cols...), types, rows)
+	if err != nil {
+		return nil, err
+	}
+	return f.keepRoles(out), nil
+}
+
+// Filter keeps the rows for which pred returns true
+func (f *Frame) Filter(pred func(Row) bool) *Frame {
+	var rows [][]interface{}
+	for _, row := range f.rows {
+		if pred(Row{frame: f, values: row}) {
+			rows = append(rows, row)
+		}
+	}
+	return f.withRows(rows)
+}
+
+// Mutate adds a column computed from each row, or replaces it if the name already exists
+func (f *Frame) Mutate(newCol string, fn func(Row) interface{}) *Frame {
+	values := make([]interface{}, len(f.rows))
+	for i, row := range f.rows {
+		values[i] = fn(Row{frame: f, values: row})
+	}
+
+	colType := "categorical"
+	for _, v := range values {
+		if v != nil {
+			colType = valueType(v)
+			break
+		}
+	}
+
+	header := append([]string{}, f.header...)
+	types := append([]string{}, f.types...)
+	col, exists := f.index[newCol]
+	if !exists {
+		col = len(header)
+		header = append(header, newCol)
+		types = append(types, colType)
+	} else {
+		types[col] = colType
+	}
+
+	rows := make([][]interface{}, len(f.rows))
+	for i, row := range f.rows {
+		rows[i] = make([]interface{}, len(header))
+		copy(rows[i], row)
+		rows[i][col] = values[i]
+	}
+
+	out, _ := newFrame(header, types, rows) // names are unique by construction
+	return f.keepRoles(out)
+}
+
+// Head returns the first n rows
+func (f *Frame) Head(n int) *Frame {
+	if n > len(f.rows) {
+		n = len(f.rows)
+	}
+	return f.withRows(f.rows[:n])
+}
+
+// withRows returns a frame with the same columns and roles but its own list of rows
+func (f *Frame) withRows(rows [][]interface{}) *Frame {
+	rows = append([][]interface{}{}, rows...)
+	return &Frame{header: f.header, types: f.types, index: f.index, rows: rows, target: f.target, weight: f.weight}
+}
+
+// keepRoles copies the target and weight roles onto a derived frame when those columns survived
+func (f *Frame) keepRoles(out *Frame) *Frame {
+	if _, ok := out.index[f.target]; ok {
+		out.target = f.target
+	}
+	if _, ok := out.index[f.weight]; ok {
+		out.weight = f.weight
+	}
+	return out
+}
+
+// Target returns the name of the target column, or "" if none is set
+func (f *Frame) Target() string {
+	return f.target
+}
+
+// SetTarget marks a column as the target to predict
+func (f *Frame) SetTarget(name string) error {
+	if _, ok := f.index[name]; !ok {
+		return fmt.Errorf("column %q not found", name)
+	}
+	f.target = name
+	return nil
+}
+
+// Weights returns the per-row sample weights from the weight column, or all ones if none is set
+func (f *Frame) Weights() []float64 {
+	weights := make([]float64, len(f.rows))
+	col, ok := f.index[f.weight]
+	for i, row := range f.rows {
+		weights[i] = 1
+		if ok && row[col] != nil {
+			weights[i] = toFloat(row[col])
+		}
+	}
+	return weights
+}
+
+// String renders the frame as an aligned text table
+func (f *Frame) String() string {
+	widths := make([]int, len(f.header))
+	cells := make([][]string, len(f.rows))
+	for j, name := range f.header {
+		widths[j] = len(name)
+	}
+	for i, row := range f.rows {
+		cells[i] = make([]string, len(row))
+		for j, v := range row {
+			cells[i][j] = formatValue(v)
+			widths[j] = max(widths[j], len(cells[i][j]))
+		}
+	}
+
+	var sb strings.Builder
+	for j, name := range f.header {
+		fmt.Fprintf(&sb, "%-*s  ", widths[j], name)
+	}
+	sb.WriteString("\n")
+	for _, row := range cells {
+		for j, cell := range row {
+			fmt.Fprintf(&sb, "%-*s  ", widths[j], cell)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formatValue renders a value the way it would appear in a CSV
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return val.Format("2006-01-02")
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// Row is a read-only view of one row that resolves values by column name
+type Row struct {
+	frame  *Frame
+	values []interface{}
+}
+
+// Get returns the value of a column, or nil if the column does not exist
+func (r Row) Get(col string) interface{} {
+	if i, ok := r.frame.index[col]; ok {
+		return r.values[i]
+	}
+	return nil
+}
+
+// Float returns a numeric column's value, or 0 if it is missing or not numeric
+func (r Row) Float(col string) float64 {
+	switch v := r.Get(col).(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return 0
+}
+
+// String returns the value of a column formatted as text
+func (r Row) String(col string) string {
+	return formatValue(r.Get(col))
+}
+
+// Values returns the raw values of the row in column order
+func (r Row) Values() []interface{} {
+	return r.values
+}
@@ -0,0 +1,258 @@
+package frame
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Agg describes one aggregation: Func applied to Column, stored in the output column As
+type Agg struct {
+	Func   string
+	Column string
+	As     string
+}
+
+// Count counts the rows of each group
+func Count() Agg {
+	return Agg{Func: "count", As: "count"}
+}
+
+// CountOf counts the non-missing values of a column in each group
+func CountOf(col string) Agg {
+	return Agg{Func: "count", Column: col, As: "count_" + col}
+}
+
+// Sum adds up a numeric column
+func Sum(col string) Agg {
+	return Agg{Func: "sum", Column: col, As: "sum_" + col}
+}
+
+// Mean averages a numeric column
+func Mean(col string) Agg {
+	return Agg{Func: "mean", Column: col, As: "mean_" + col}
+}
+
+// Min takes the smallest value of a numeric, date or categorical column
+func Min(col string) Agg {
+	return Agg{Func: "min", Column: col, As: "min_" + col}
+}
+
+// Max takes the largest value of a numeric, date or categorical column
+func Max(col string) Agg {
+	return Agg{Func: "max", Column: col, As: "max_" + col}
+}
+
+// Grouped is a frame split into groups of rows sharing the same key column values
+type Grouped struct {
+	frame  *Frame
+	keys   []string
+	order  []string
+	groups map[string][][]interface{}
+}
+
+// GroupBy groups rows by the values of the key columns. Groups keep the order in which
+// their first row appears.
+func (f *Frame) GroupBy(keys ...string) (*Grouped, error) {
+	positions := make([]int, len(keys))
+	for i, name := range keys {
+		col, ok := f.index[name]
+		if !ok {
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+		positions[i] = col
+	}
+
+	g := &Grouped{frame: f, keys: keys, groups: make(map[string][][]interface{})}
+	parts := make([]string, len(keys))
+	for _, row := range f.rows {
+		for i, col := range positions {
+			parts[i] = formatValue(row[col])
+		}
+		key := strings.Join(parts, "\x1f")
+		if _, seen := g.groups[key]; !seen {
+			g.order = append(g.order, key)
+		}
+		g.groups[key] = append(g.groups[key], row)
+	}
+	return g, nil
+}
+
+// Agg computes the aggregations for every group, returning one row per group made of
+// the key columns followed by one column per aggregation
+func (g *Grouped) Agg(aggs ...Agg) (*Frame, error) {
+	f := g.frame
+	header := append([]string{}, g.keys...)
+	var types []string
+	for _, key := range g.keys {
+		types = append(types, f.types[f.index[key]])
+	}
+
+	positions := make([]int, len(aggs))
+	for i, agg := range aggs {
+		header = append(header, agg.As)
+		if agg.Func == "count" && agg.Column == "" {
+			positions[i] = -1
+			types = append(types, "numeric")
+			continue
+		}
+		col, ok := f.index[agg.Column]
+		if !ok {
+			return nil, fmt.Errorf("column %q not found", agg.Column)
+		}
+		positions[i] = col
+		switch agg.Func {
+		case "count":
+			types = append(types, "numeric")
+		case "sum", "mean":
+			if f.types[col] != "numeric" {
+				return nil, fmt.Errorf("cannot %s non-numeric column %q", agg.Func, agg.Column)
+			}
+			types = append(types, "numeric")
+		case "min", "max":
+			types = append(types, f.types[col])
+		default:
+			return nil, fmt.Errorf("unknown aggregation %q", agg.Func)
+		}
+	}
+
+	var rows [][]interface{}
+	for _, key := range g.order {
+		members := g.groups[key]
+		var row []interface{}
+		for _, name := range g.keys {
+			row = append(row, members[0][f.index[name]])
+		}
+		for i, agg := range aggs {
+			row = append(row, aggregate(agg.Func, members, positions[i]))
+		}
+		rows = append(rows, row)
+	}
+	return newFrame(header, types, rows)
+}
+
+// aggregate applies one aggregation function to a column of the given rows, skipping nil
+// values. A count with no column (col -1) counts the rows themselves.
+func aggregate(fn string, rows [][]interface{}, col int) interface{} {
+	if fn == "count" && col < 0 {
+		return float64(len(rows))
+	}
+
+	var result interface{}
+	sum, n := 0.0, 0
+	for _, row := range rows {
+		v := row[col]
+		if v == nil {
+			continue
+		}
+		switch fn {
+		case "count":
+			n++
+		case "sum", "mean":
+			sum += toFloat(v)
+			n++
+		case "min":
+			if result == nil || compareValues(v, result) < 0 {
+				result = v
+			}
+		case "max":
+			if result == nil || compareValues(v, result) > 0 {
+				result = v
+			}
+		}
+	}
+
+	switch fn {
+	case "count":
+		return float64(n)
+	case "sum":
+		return sum
+	case "mean":
+		if n == 0 {
+			return nil
+		}
+		return sum / float64(n)
+	}
+	return result
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(v interface{}) float64 {
+	switch val := v.(type) {
+	case float64:
+		return val
+	case int:
+		return float64(val)
+	}
+	return 0
+}
+
+// compareValues orders two values of the same column type, returning -1, 0 or 1
+func compareValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case time.Time:
+		return av.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(av, b.(string))
+	}
+	x, y := toFloat(a), toFloat(b)
+	if x < y {
+		return -1
+	} else if x > y {
+		return 1
+	}
+	return 0
+}
+
+// ParseAggs parses a comma-separated list of aggregations such as
+// "count(*), mean(amount), max(date) as last_seen". Each is a function (count, sum, mean,
+// min or max) of a column, or count(*) for the number of rows, optionally renamed with "as".
+func ParseAggs(spec string) ([]Agg, error) {
+	var aggs []Agg
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		as := ""
+		if i := strings.LastIndex(strings.ToLower(part), " as "); i != -1 {
+			as = strings.TrimSpace(part[i+4:])
+			part = strings.TrimSpace(part[:i])
+		}
+		open := strings.Index(part, "(")
+		if open <= 0 || !strings.HasSuffix(part, ")") {
+			return nil, fmt.Errorf("aggregation %q is not of the form func(column)", part)
+		}
+		fn := strings.ToLower(strings.TrimSpace(part[:open]))
+		col := strings.TrimSpace(part[open+1 : len(part)-1])
+
+		var agg Agg
+		switch {
+		case fn == "count" && col == "*":
+			agg = Count()
+		case col == "" || col == "*":
+			return nil, fmt.Errorf("aggregation %q needs a column", part)
+		case fn == "count":
+			agg = CountOf(col)
+		case fn == "sum":
+			agg = Sum(col)
+		case fn == "mean" || fn == "avg":
+			agg = Mean(col)
+		case fn == "min":
+			agg = Min(col)
+		case fn == "max":
+			agg = Max(col)
+		default:
+			return nil, fmt.Errorf("unknown aggregation %q (use count, sum, mean, min or max)", fn)
+		}
+		if as != "" {
+			agg.As = as
+		}
+		aggs = append(aggs, agg)
+	}
+	if len(aggs) == 0 {
+		return nil, fmt.Errorf("no aggregations given")
+	}
+	return aggs, nil
+}
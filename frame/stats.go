@@ -0,0 +1,210 @@
+package frame
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// ColumnStats summarises one column. Min and Max hold values of the column's type;
+// Mean is only set for numeric columns and Counts only for categorical ones.
+type ColumnStats struct {
+	Type        string
+	Count       int
+	Missing     int
+	Min         interface{}
+	Max         interface{}
+	Mean        float64
+	Cardinality int
+	Counts      map[string]int
+
+	distinct map[string]int // occurrences of every value, kept so Append can update the stats
+	sum      float64
+}
+
+// Stats returns the statistics of a column. They are computed on first use and cached
+// until the frame is modified, so repeated queries do not rescan the column. Append
+// updates cached statistics with the new rows instead of dropping them.
+func (f *Frame) Stats(name string) (ColumnStats, error) {
+	col, ok := f.index[name]
+	if !ok {
+		return ColumnStats{}, fmt.Errorf("column %q not found", name)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats, cached := f.stats[col]
+	if !cached {
+		if f.stats == nil {
+			f.stats = make(map[int]*ColumnStats)
+		}
+		stats = f.computeStats(col)
+		f.stats[col] = stats
+	}
+	// Counts is copied because Append keeps updating the cached map
+	out := *stats
+	out.Counts = maps.Clone(stats.Counts)
+	return out, nil
+}
+
+// computeStats scans one column
+func (f *Frame) computeStats(col int) *ColumnStats {
+	stats := &ColumnStats{Type: f.types[col], distinct: make(map[string]int)}
+	if stats.Type == "categorical" {
+		stats.Counts = stats.distinct
+	}
+	for _, row := range f.rows {
+		stats.add(row[col])
+	}
+	return stats
+}
+
+// add folds one more value into the statistics
+func (s *ColumnStats) add(v interface{}) {
+	if v == nil {
+		s.Missing++
+		return
+	}
+	s.Count++
+	s.distinct[formatValue(v)]++
+	s.Cardinality = len(s.distinct)
+	if s.Type == "numeric" {
+		s.sum += toFloat(v)
+		s.Mean = s.sum / float64(s.Count)
+	}
+	if s.Min == nil || compareValues(v, s.Min) < 0 {
+		s.Min = v
+	}
+	if s.Max == nil || compareValues(v, s.Max) > 0 {
+		s.Max = v
+	}
+}
+
+// invalidate drops cached statistics after the frame's data changes
+func (f *Frame) invalidate() {
+	f.mu.Lock()
+	f.stats = nil
+	f.mu.Unlock()
+}
+
+// Set replaces one value in place. Rows are copied before writing so frames derived
+// from this one are not affected, and the cached statistics are invalidated.
+func (f *Frame) Set(row int, name string, value interface{}) error {
+	col, ok := f.index[name]
+	if !ok {
+		return fmt.Errorf("column %q not found", name)
+	}
+	if row < 0 || row >= len(f.rows) {
+		return fmt.Errorf("row %d out of range", row)
+	}
+	if value != nil && valueType(value) != f.types[col] {
+		return fmt.Errorf("cannot store %s value in %s column %q", valueType(value), f.types[col], name)
+	}
+
+	updated := append([]interface{}{}, f.rows[row]...)
+	updated[col] = value
+	f.rows[row] = updated
+	f.invalidate()
+	return nil
+}
+
+// Append adds rows to the end of the frame in place. Values must match their column's
+// type, except that a column holding no values yet takes the type of the first value
+// appended to it. Cached statistics, and with them ClassCounts, are updated from the new
+// rows rather than recomputed, so a frame that keeps accumulating rows stays cheap to query.
+func (f *Frame) Append(rows ...[]interface{}) error {
+	// Check every row before changing anything, so a bad row leaves the frame as it was
+	types := append([]string{}, f.types...)
+	retyped := make(map[int]bool)
+	for i, row := range rows {
+		if len(row) != len(f.header) {
+			return fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(f.header))
+		}
+		for col, v := range row {
+			if v == nil || valueType(v) == types[col] {
+				continue
+			}
+			if f.allMissing(col) && !typedEarlier(rows[:i], col) {
+				types[col] = valueType(v)
+				retyped[col] = true
+				continue
+			}
+			return fmt.Errorf("row %d: cannot store %s value in %s column %q", i, valueType(v), types[col], f.header[col])
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.types = types // a fresh slice, as derived frames share the old one
+	for _, row := range rows {
+		f.rows = append(f.rows, append([]interface{}{}, row...))
+	}
+	for col, stats := range f.stats {
+		if retyped[col] {
+			delete(f.stats, col)
+			continue
+		}
+		for _, row := range rows {
+			stats.add(row[col])
+		}
+	}
+	return nil
+}
+
+// allMissing reports whether a column holds no values at all
+func (f *Frame) allMissing(col int) bool {
+	for _, row := range f.rows {
+		if row[col] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// typedEarlier reports whether any of the rows has a value in a column
+func typedEarlier(rows [][]interface{}, col int) bool {
+	for _, row := range rows {
+		if row[col] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe renders the statistics of every column as a text table
+func (f *Frame) Describe() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-20s %-12s %8s %8s %12s %12s %12s %8s\n", "column", "type", "count", "missing", "min", "max", "mean", "distinct")
+	for _, name := range f.header {
+		stats, _ := f.Stats(name)
+		mean := ""
+		if stats.Type == "numeric" {
+			mean = fmt.Sprintf("%.4f", stats.Mean)
+		}
+		fmt.Fprintf(&sb, "%-20s %-12s %8d %8d %12s %12s %12s %8d\n", name, stats.Type, stats.Count, stats.Missing,
+			formatValue(stats.Min), formatValue(stats.Max), mean, stats.Cardinality)
+	}
+	return sb.String()
+}
+
+// ClassCounts returns the counts of each value of a categorical column, sorted by value
+func (f *Frame) ClassCounts(name string) ([]string, []int, error) {
+	stats, err := f.Stats(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stats.Counts == nil {
+		return nil, nil, fmt.Errorf("column %q is not categorical", name)
+	}
+	classes := make([]string, 0, len(stats.Counts))
+	for class := range stats.Counts {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	counts := make([]int, len(classes))
+	for i, class := range classes {
+		counts[i] = stats.Counts[class]
+	}
+	return classes, counts, nil
+}
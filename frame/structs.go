@@ -0,0 +1,134 @@
+package frame
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// FromStructs builds a frame from a slice of structs, one column per exported field.
+// The `frame` tag sets the column name and an optional role:
+//
+//	Outlook    string  `frame:"outlook"`
+//	PlayTennis string  `frame:"play,target"`
+//	Weight     float64 `frame:",weight"`
+//	ID         string  `frame:"-"`
+//
+// Strings and bools become categorical, numbers numeric and time.Time dates.
+// Nil pointer fields become missing values.
+func FromStructs[T any](items []T) (*Frame, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("FromStructs needs a struct type, got %s", t)
+	}
+
+	var header, types []string
+	var fields []int
+	var target, weight string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, role := field.Name, ""
+		if tag, ok := field.Tag.Lookup("frame"); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			if len(parts) > 1 {
+				role = parts[1]
+			}
+		}
+
+		colType, err := fieldType(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+
+		switch role {
+		case "":
+		case "ignore":
+			continue
+		case "target":
+			target = name
+		case "weight":
+			if colType != "numeric" {
+				return nil, fmt.Errorf("weight field %s must be numeric", field.Name)
+			}
+			weight = name
+		default:
+			return nil, fmt.Errorf("field %s: unknown role %q (use target, weight or ignore)", field.Name, role)
+		}
+
+		header = append(header, name)
+		types = append(types, colType)
+		fields = append(fields, i)
+	}
+
+	rows := make([][]interface{}, len(items))
+	for r, item := range items {
+		v := reflect.ValueOf(item)
+		rows[r] = make([]interface{}, len(fields))
+		for j, i := range fields {
+			rows[r][j] = fieldValue(v.Field(i))
+		}
+	}
+
+	f, err := newFrame(header, types, rows)
+	if err != nil {
+		return nil, err
+	}
+	f.target, f.weight = target, weight
+	return f, nil
+}
+
+// fieldType maps a struct field type to a column type
+func fieldType(t reflect.Type) (string, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return "date", nil
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool:
+		return "categorical", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "numeric", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// fieldValue converts a struct field into the frame's value representation
+func fieldValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return fmt.Sprintf("%t", v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
@@ -195,15 +195,7 @@ func BuildDecisionTree(dataset [][]string, header []string) *TreeNode {
 	bestAttr := BestAttribute(dataset, header)
 	if bestAttr == "" {
 		// If no good split is found, return the most common class
-		mostCommonClass := ""
-		maxCount := 0
-		for class, count := range classCounts {
-			if count > maxCount {
-				maxCount = count
-				mostCommonClass = class
-			}
-		}
-		return &TreeNode{Class: mostCommonClass, IsLeaf: true}
+		return majorityLeaf(classCounts)
 	}
 
 	// Create a new decision tree node
@@ -211,6 +203,10 @@ func BuildDecisionTree(dataset [][]string, header []string) *TreeNode {
 
 	// Split the dataset based on the best attribute
 	splitted := SplitDataset(dataset, header, bestAttr)
+	if len(splitted) < 2 {
+		// Rows that agree on every attribute but differ in class cannot be split further
+		return majorityLeaf(classCounts)
+	}
 
 	for attrValue, subset := range splitted {
 		node.Children[attrValue] = BuildDecisionTree(subset, header)
@@ -219,6 +215,48 @@ func BuildDecisionTree(dataset [][]string, header []string) *TreeNode {
 	return node
 }
 
+// majorityLeaf returns a leaf predicting the most common class
+func majorityLeaf(classCounts map[string]int) *TreeNode {
+	mostCommonClass := ""
+	maxCount := 0
+	for class, count := range classCounts {
+		if count > maxCount {
+			maxCount = count
+			mostCommonClass = class
+		}
+	}
+	return &TreeNode{Class: mostCommonClass, IsLeaf: true}
+}
+
+// moveTarget returns the header and rows with the target column moved to the end, where
+// the tree builder expects the class, so it is never picked as a split attribute
+func moveTarget(header []string, dataset [][]string, targetCol string) ([]string, [][]string, error) {
+	targetIndex := -1
+	for i, col := range header {
+		if col == targetCol {
+			targetIndex = i
+		}
+	}
+	if targetIndex == -1 {
+		return nil, nil, fmt.Errorf("target column %q not found", targetCol)
+	}
+
+	reorder := func(values []string) []string {
+		moved := make([]string, 0, len(values))
+		moved = append(moved, values[:targetIndex]...)
+		moved = append(moved, values[targetIndex+1:]...)
+		return append(moved, values[targetIndex])
+	}
+	rows := make([][]string, len(dataset))
+	for i, row := range dataset {
+		if len(row) != len(header) {
+			return nil, nil, fmt.Errorf("row %d has %d values, expected %d", i+1, len(row), len(header))
+		}
+		rows[i] = reorder(row)
+	}
+	return reorder(header), rows, nil
+}
+
 // Train decision tree and save model
 func TrainModel(inputFile, targetCol, outputFile string) error {
 	// Load dataset
@@ -226,6 +264,10 @@ func TrainModel(inputFile, targetCol, outputFile string) error {
 	if err != nil {
 		return err
 	}
+	header, dataset, err = moveTarget(header, dataset, targetCol)
+	if err != nil {
+		return err
+	}
 
 	// Train decision tree
 	tree := BuildDecisionTree(dataset, header)
@@ -0,0 +1,10 @@
+//go:build !(js && wasm)
+
+package main
+
+import "os"
+
+func main() {
+	runCLI()
+	os.Exit(runResult.ExitCode)
+}
@@ -1,528 +1,439 @@
 package main
 
 import (
-	"encoding/csv"
+	"flag"
 	"fmt"
-	"os"
-	"strconv"
+	"strings"
 	"time"
-	"math"
-	"sort"
-	"encoding/json"
-	"flag"
-)
-
-// LoadCsv loads a CSV file and detects data types (categorical, numeric, date)
-func LoadCsv(filename string) ([]string, [][]interface{}, []string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error opening file: %v", err)
-	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error reading file: %v", err)
-	}
+	"machineLearning/dtree"
+)
 
-	if len(records) < 2 {
-		return nil, nil, nil, fmt.Errorf("insufficient data in CSV file")
-	}
+// stringList collects the values of a flag that may be given several times
+type stringList []string
 
-	header := records[0]
-	rawData := records[1:]
-
-	// Detect column data types
-	colTypes := detectColumnTypes(rawData)
-
-	// Convert dataset based on detected types
-	var dataset [][]interface{}
-	for _, row := range rawData {
-		var convertedRow []interface{}
-		for i, val := range row {
-			switch colTypes[i] {
-			case "numeric":
-				num, _ := strconv.ParseFloat(val, 64)
-				convertedRow = append(convertedRow, num)
-			case "date":
-				parsedTime, _ := parseDate(val)
-				convertedRow = append(convertedRow, parsedTime)
-			default:
-				convertedRow = append(convertedRow, val) // Keep as string
-			}
-		}
-		dataset = append(dataset, convertedRow)
-	}
-
-	return header, dataset, colTypes, nil
+func (l *stringList) String() string {
+	return strings.Join(*l, "; ")
 }
 
-// detectColumnTypes determines if each column is categorical, numeric, or a date
-func detectColumnTypes(data [][]string) []string {
-	colCount := len(data[0])
-	colTypes := make([]string, colCount)
-
-	for col := 0; col < colCount; col++ {
-		isNumeric := true
-		isDate := true
-
-		for row := 0; row < len(data); row++ {
-			if _, err := strconv.ParseFloat(data[row][col], 64); err != nil {
-				isNumeric = false
-			}
-			if _, err := parseDate(data[row][col]); err != nil {
-				isDate = false
-			}
-		}
-
-		if isNumeric {
-			colTypes[col] = "numeric"
-		} else if isDate {
-			colTypes[col] = "date"
-		} else {
-			colTypes[col] = "categorical"
-		}
-	}
-	return colTypes
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
-// parseDate tries to parse a string into a time.Time object
-func parseDate(value string) (time.Time, error) {
-	formats := []string{"2006-01-02", "02/01/2006", "01-02-2006", "2006/01/02"}
-	for _, format := range formats {
-		t, err := time.Parse(format, value)
-		if err == nil {
-			return t, nil
-		}
+// loadCosts loads the cost matrix named by the -costs flag, or returns nil when none is given
+func loadCosts(filename string) (dtree.CostMatrix, error) {
+	if filename == "" {
+		return nil, nil
 	}
-	return time.Time{}, fmt.Errorf("invalid date format: %s", value)
+	return dtree.LoadCostMatrix(filename)
 }
 
-
-// CountClassOccurrences counts occurrences of each target class in the dataset
-func CountClassOccurrences(dataset [][]interface{}) map[string]int {
-	classCounts := make(map[string]int)
-
-	for _, row := range dataset {
-		if len(row) == 0 {
-			continue
-		}
-		targetClass, ok := row[len(row)-1].(string) // Ensure it's categorical
-		if !ok {
-			continue // Skip if it's not a string (categorical class)
-		}
-		classCounts[targetClass]++
+// checkAbstention validates the -min-confidence and -reject-label flags
+func checkAbstention(a dtree.Abstention) error {
+	if a.MinConfidence < 0 || a.MinConfidence > 1 {
+		return dtree.UsageError(fmt.Errorf("min-confidence must be between 0 and 1, got %g", a.MinConfidence))
 	}
-
-	return classCounts
-}
-
-
-// ComputeProbabilities calculates the probability of each class in the dataset
-func ComputeProbabilities(classCounts map[string]int, totalSamples int) map[string]float64 {
-	probabilities := make(map[string]float64)
-
-	for class, count := range classCounts {
-		probabilities[class] = float64(count) / float64(totalSamples)
+	if a.MinConfidence > 0 && a.Label == "" {
+		return dtree.UsageError(fmt.Errorf("reject-label must not be empty"))
 	}
-	return probabilities
+	return nil
 }
 
-
-// Entropy calculates the entropy of the dataset (impurity measure)
-func Entropy(dataset [][]interface{}) float64 {
-	countClassOccurrences := CountClassOccurrences(dataset)
-	totalSamples := len(dataset)
-	if totalSamples == 0 {
-		return 0.0
+// runCLI parses the command line and runs the dt command it names
+func runCLI() {
+	// Define CLI flags
+	loadOpts := dtree.DefaultLoadOptions()
+	command := flag.String("c", "", "Command: train, predict, evaluate, cv, errors, compare, counterfactual, inspect, join, aggregate, shard or merge-models")
+	inputFile := flag.String("i", "", "Input CSV file")
+	var targetCols stringList
+	flag.Var(&targetCols, "t", "Target column (for training and evaluation); repeat to handle several targets in one pass")
+	modelFile := flag.String("m", "", "Model file (for prediction and evaluation)")
+	modelFile2 := flag.String("m2", "", "Second model file, compared against -m (compare)")
+	outputFile := flag.String("o", "", "Output file")
+	instanceJSON := flag.String("json", "", "Instance to explain as a JSON object (counterfactual)")
+	desiredClass := flag.String("target", "", "Class the counterfactual should reach")
+	contributions := flag.Bool("contributions", false, "Append the bias and per-feature contributions of each prediction (predict)")
+	summary := flag.Bool("summary", false, "Print the predicted class distribution, mean class probabilities and a confidence histogram (predict)")
+	maxLeaves := flag.Int("max-leaves", 0, "Grow the tree best-first up to this many leaves (0 = grow depth-first until pure)")
+	maxDepth := flag.Int("max-depth", 0, "Stop splitting below this depth and predict the majority class (0 = unlimited; train, cv)")
+	minSamplesSplit := flag.Int("min-samples-split", 0, "Only split nodes with at least this many training rows (train, cv)")
+	minSamplesLeaf := flag.Int("min-samples-leaf", 0, "Only consider splits leaving at least this many training rows in every child (train, cv)")
+	top := flag.Int("top", 50, "Number of most confidently wrong predictions to list (errors; 0 = all)")
+	folds := flag.Int("k", 5, "Number of cross-validation folds")
+	foldDir := flag.String("save-folds", "", "Directory to save each fold's model and out-of-fold predictions in (cv)")
+	workers := flag.Int("workers", 0, "Folds trained in parallel (0 = one per CPU)")
+	seed := flag.Int64("seed", 1, "Random seed for fold assignment and private training noise")
+	dpEpsilon := flag.Float64("dp-epsilon", 0, "Train with differential privacy under this epsilon budget (0 = off)")
+	dpDepth := flag.Int("dp-depth", 0, "Maximum depth of a differentially private tree, like -max-depth (0 = 4)")
+	dpDomains := flag.String("dp-domains", "", "JSON file declaring the categories or numeric range of each feature a private tree may split on (required with -dp-epsilon)")
+	interactionGroups := flag.String("interaction-groups", "", "Feature groups a path may combine, e.g. \"geo:lat,lon; time:hour,dow\" (train, cv)")
+	featurePenalty := flag.String("feature-penalty", "", "Split score multipliers that discourage features, e.g. \"Cost=0.5,Sensor=0.8\" (train, cv)")
+	honest := flag.Bool("honest", false, "Choose splits on half of the rows and estimate class counts on the other half (train, cv)")
+	keepAllColumns := flag.Bool("keep-all-columns", false, "Let training split on constant and ID-like columns, which are skipped by default")
+	leafSamples := flag.Int("leaf-samples", 0, "Keep up to this many training rows in each leaf for inspect -leaf (0 = none)")
+	leaf := flag.Int("leaf", 0, "Leaf number to show with its training rows (inspect)")
+	flag.IntVar(&loadOpts.SampleRows, "sample-rows", loadOpts.SampleRows, "Rows sampled to infer column types (0 = all)")
+	flag.StringVar(&loadOpts.CacheDir, "cache-dir", loadOpts.CacheDir, "Directory for caching parsed datasets between runs (empty = no cache)")
+	flag.BoolVar(&loadOpts.Mmap, "mmap", loadOpts.Mmap, "Memory-map the input CSV instead of reading it through a buffer")
+	naTokens := flag.String("na", "", "Comma-separated values that mean missing, e.g. \"NA,N/A,null,-,?\" (stored in trained models)")
+	flag.BoolVar(&loadOpts.Normalize.Trim, "trim", false, "Strip whitespace around categorical feature values (stored in trained models)")
+	flag.BoolVar(&loadOpts.Normalize.Collapse, "collapse-spaces", false, "Trim categorical feature values and collapse internal whitespace to one space (stored in trained models)")
+	flag.BoolVar(&loadOpts.Normalize.FoldCase, "fold-case", false, "Lower-case categorical feature values (stored in trained models)")
+	flag.StringVar(&loadOpts.Encoding, "encoding", loadOpts.Encoding, "Input encoding: auto, utf-8 or windows-1252")
+	var renames stringList
+	flag.Var(&renames, "rename", "Rename a column while loading, as old=new (repeatable; recorded as an alias in trained models)")
+	flag.BoolVar(&loadOpts.Strict, "strict", false, "Fail on duplicate columns and unparsable values instead of treating them as missing")
+	flag.StringVar(&loadOpts.Filter, "filter", loadOpts.Filter, "Load only the rows matching this expression, e.g. \"Temperature > 60 && Outlook != 'Rainy'\"")
+	leftFile := flag.String("left", "", "Left CSV file (join)")
+	rightFile := flag.String("right", "", "Right CSV file (join)")
+	joinOn := flag.String("on", "", "Key column shared by both files (join)")
+	joinHow := flag.String("how", "inner", "Join type: inner or left (join)")
+	sorted := flag.Bool("sorted", false, "Both join inputs are sorted by the key; stream them instead of loading the right file")
+	groupBy := flag.String("groupby", "", "Comma-separated key columns (aggregate)")
+	parts := flag.Int("parts", 0, "Number of shards to split the input into (shard)")
+	aggSpec := flag.String("agg", "", "Aggregations such as \"count(*), mean(amount), max(date)\" (aggregate)")
+	var derive stringList
+	flag.Var(&derive, "derive", "Add a computed column before training, e.g. \"TempDiff = MaxTemp - MinTemp\" (repeatable)")
+	keyFile := flag.String("encrypt-key-file", "", "File holding a hex AES key for encrypting and decrypting models; unencrypted models are then refused (default: $"+dtree.ModelKeyEnv+")")
+	weightCol := flag.String("weight-column", "", "Numeric column to weight rows by in case-weighted metrics, e.g. revenue (evaluate)")
+	costFile := flag.String("costs", "", "Misclassification cost matrix CSV; predict the class of lowest expected cost (predict, evaluate)")
+	confusionFile := flag.String("confusion-out", "", "Write the confusion matrix to this .csv file or .html heat map, cost-weighted with -costs (evaluate)")
+	sliceBy := flag.String("slice-by", "", "Comma-separated columns to break evaluation metrics down by, e.g. Outlook,Region (evaluate)")
+	minSupport := flag.Int("min-support", 10, "Fewest rows a -slice-by value needs to be reported (evaluate)")
+	minConfidence := flag.Float64("min-confidence", 0, "Abstain from predictions whose top class probability is below this (0 = off) (predict, evaluate)")
+	rejectLabel := flag.String("reject-label", "REVIEW", "Label given to abstained rows (predict)")
+	minAccuracy := flag.Float64("min-accuracy", 0, "Fail train and evaluate with exit code 6 when accuracy is below this (0 = off; train then saves no model)")
+	flag.Float64Var(&loadOpts.TypeTolerance, "type-tolerance", loadOpts.TypeTolerance, "Fraction of sampled values that must parse for a numeric or date column")
+
+	quiet := flag.Bool("quiet", false, "Print nothing but a final error line on stderr")
+	jsonOutput := flag.Bool("json-output", false, "Print only a JSON object with the command's outputs, metrics, duration and any error")
+
+	// Parse flags, which may also follow positional arguments (merge-models a.dt b.dt -o out.dt)
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
 	}
 
-	probabilities := ComputeProbabilities(countClassOccurrences, totalSamples)
-	entropy := 0.0
-
-	for _, probability := range probabilities {
-		if probability > 0 {
-			entropy -= probability * math.Log2(probability)
+	start := time.Now()
+	runResult.Command = *command
+	if *inputFile != "" {
+		runResult.Inputs = append(runResult.Inputs, *inputFile)
+	}
+	if *modelFile != "" {
+		runResult.Inputs = append(runResult.Inputs, *modelFile)
+	}
+	if *modelFile2 != "" {
+		runResult.Inputs = append(runResult.Inputs, *modelFile2)
+	}
+	if *quiet || *jsonOutput {
+		restore, err := silenceStdout()
+		if err != nil {
+			fail(err)
+			return
 		}
+		defer finishRun(start, *jsonOutput)
+		defer restore()
 	}
-	return entropy
-}
-
 
-// SplitDataset handles both categorical and numerical attributes
-func SplitDataset(dataset [][]interface{}, header []string, attribute string) map[string][][]interface{} {
-	subsets := make(map[string][][]interface{})
-
-	attrIndex := -1
-	for i, col := range header {
-		if col == attribute {
-			attrIndex = i
-			break
+	for _, rename := range renames {
+		old, renamed, ok := strings.Cut(rename, "=")
+		if !ok || old == "" || renamed == "" {
+			fail(dtree.UsageError(fmt.Errorf("-rename expects old=new, got %s", rename)))
+			return
 		}
+		if loadOpts.Rename == nil {
+			loadOpts.Rename = make(map[string]string)
+		}
+		loadOpts.Rename[old] = renamed
 	}
-
-	if attrIndex == -1 {
-		fmt.Println("Error: Attribute not found in header")
-		return subsets
+	if *naTokens != "" {
+		loadOpts.NATokens = strings.Split(*naTokens, ",")
 	}
 
-	// Check the type of the attribute (categorical or numerical)
-	switch dataset[0][attrIndex].(type) {
-	case string:
-		// Categorical split
-		for _, row := range dataset {
-			if attrIndex < len(row) {
-				key, _ := row[attrIndex].(string)
-				subsets[key] = append(subsets[key], row)
+	key, err := dtree.LoadModelKey(*keyFile)
+	if err != nil {
+		fail(err)
+		return
+	}
+	loader := dtree.NewLoader(loadOpts, key)
+	defer reportWarnings(loader)
+
+	// buildTrainOpts turns the training flags into options, shared by train and cv so that
+	// cross-validation scores the model train would save
+	buildTrainOpts := func() ([]dtree.Option, error) {
+		trainOpts := []dtree.Option{dtree.WithMaxLeaves(*maxLeaves), dtree.WithSeed(*seed), dtree.WithLeafSamples(*leafSamples),
+			dtree.WithMaxDepth(*maxDepth), dtree.WithMinSamplesSplit(*minSamplesSplit), dtree.WithMinSamplesLeaf(*minSamplesLeaf)}
+		if *dpEpsilon > 0 {
+			if *dpDomains == "" {
+				return nil, dtree.UsageError(fmt.Errorf("-dp-epsilon needs -dp-domains: private trees do not read feature domains from the training rows"))
+			}
+			if *dpDepth > 0 && *maxDepth > 0 {
+				return nil, dtree.UsageError(fmt.Errorf("-dp-depth and -max-depth both limit the private tree's depth; give only one"))
+			}
+			domains, err := dtree.LoadDomains(*dpDomains)
+			if err != nil {
+				return nil, err
+			}
+			trainOpts = append(trainOpts, dtree.WithPrivacy(*dpEpsilon, domains))
+			if *dpDepth > 0 {
+				trainOpts = append(trainOpts, dtree.WithMaxDepth(*dpDepth))
 			}
 		}
-	default:
-		// Numeric or date split (find best threshold)
-		bestThreshold, leftSubset, rightSubset := FindBestThreshold(dataset, attrIndex)
-		subsets[fmt.Sprintf("<=%.2f", bestThreshold)] = leftSubset
-		subsets[fmt.Sprintf(">%.2f", bestThreshold)] = rightSubset
-	}
-
-	return subsets
-}
-
-// FindBestThreshold finds the best threshold to split a numeric attribute
-func FindBestThreshold(dataset [][]interface{}, attrIndex int) (float64, [][]interface{}, [][]interface{}) {
-	var values []float64
-	for _, row := range dataset {
-		if v, ok := row[attrIndex].(float64); ok {
-			values = append(values, v)
-		} else if v, ok := row[attrIndex].(string); ok {
-			parsedTime, err := time.Parse("2006-01-02", v) // Example: YYYY-MM-DD
-			if err == nil {
-				values = append(values, float64(parsedTime.Unix())) // Convert date to numeric value
+		if *keepAllColumns {
+			trainOpts = append(trainOpts, dtree.WithAllColumns())
+		}
+		if *honest {
+			trainOpts = append(trainOpts, dtree.WithHonesty())
+		}
+		if *interactionGroups != "" {
+			groups, err := dtree.ParseInteractionGroups(*interactionGroups)
+			if err != nil {
+				return nil, dtree.UsageError(err)
 			}
+			trainOpts = append(trainOpts, dtree.WithInteractionGroups(groups...))
 		}
-	}
-
-	sort.Float64s(values) // Sort values to find optimal threshold
-	bestThreshold := values[len(values)/2]
-
-	var leftSubset, rightSubset [][]interface{}
-	for _, row := range dataset {
-		val, _ := row[attrIndex].(float64)
-		if val <= bestThreshold {
-			leftSubset = append(leftSubset, row)
-		} else {
-			rightSubset = append(rightSubset, row)
+		if *featurePenalty != "" {
+			penalties, err := dtree.ParseFeaturePenalties(*featurePenalty)
+			if err != nil {
+				return nil, dtree.UsageError(err)
+			}
+			trainOpts = append(trainOpts, penalties...)
 		}
+		return trainOpts, nil
 	}
 
-	return bestThreshold, leftSubset, rightSubset
-}
-
-// InformationGain calculates how much information is gained by splitting on an attribute
-func InformationGain(dataset [][]interface{}, header []string, attribute string) float64 {
-	totalSamples := len(dataset)
-	if totalSamples == 0 {
-		return 0
-	}
-
-	initialEntropy := Entropy(dataset)
-	splitted := SplitDataset(dataset, header, attribute)
-
-	weightedEntropy := 0.0
-	for _, subset := range splitted {
-		proportion := float64(len(subset)) / float64(totalSamples)
-		weightedEntropy += proportion * Entropy(subset)
-	}
-
-	informationGain := initialEntropy - weightedEntropy
-	return informationGain
-}
-
-// GainRatio calculates the gain ratio, a normalized version of information gain
-func GainRatio(dataset [][]interface{}, header []string, attribute string) float64 {
-	totalSamples := len(dataset)
-	if totalSamples == 0 {
-		return 0
-	}
-
-	infoGain := InformationGain(dataset, header, attribute)
-	if infoGain == 0 {
-		return 0
-	}
-
-	splitted := SplitDataset(dataset, header, attribute)
-
-	splitInfo := 0.0
-	for _, subset := range splitted {
-		proportion := float64(len(subset)) / float64(totalSamples)
-		if proportion > 0 {
-			splitInfo -= proportion * math.Log2(proportion)
+	// Execute command
+	switch *command {
+	case "train":
+		if *inputFile == "" || len(targetCols) == 0 || *outputFile == "" {
+			usage("Usage: dt -c train -i <input.csv> -t <target> [-t <target2>...] -o <model.dt> [-max-leaves N] [-max-depth N] [-min-samples-split N] [-min-samples-leaf N] [-dp-epsilon 1 -dp-domains <domains.json> -dp-depth 4] [-leaf-samples 5] [-derive \"Name = expr\"] [-min-accuracy 0.8]")
+			return
 		}
-	}
-
-	if splitInfo == 0 {
-		return 0
-	}
-
-	gainRatio := infoGain / splitInfo
-	return gainRatio
-}
-
-// BestAttribute finds the attribute with the highest Gain Ratio and returns it.
-func BestAttribute(dataset [][]interface{}, header []string) string {
-	bestAttr := ""
-	bestGainRatio := -1.0
-
-	for _, attr := range header[:len(header)-1] { // Exclude target variable
-		gainRatio := GainRatio(dataset, header, attr)
-
-		if gainRatio > bestGainRatio {
-			bestGainRatio = gainRatio
-			bestAttr = attr
+		trainOpts, err := buildTrainOpts()
+		if err != nil {
+			fail(err)
+			return
 		}
-	}
-
-	return bestAttr
-}
-
-type TreeNode struct {
-	Attribute  string
-	Threshold  float64
-	Children   map[string]*TreeNode
-	Class      string
-	IsLeaf     bool
-}
-
-// BuildDecisionTree constructs a decision tree based on the dataset.
-func BuildDecisionTree(dataset [][]interface{}, header []string) *TreeNode {
-	classCounts := CountClassOccurrences(dataset)
-
-	// If all samples belong to the same class, return a leaf node
-	if len(classCounts) == 1 {
-		for class := range classCounts {
-			return &TreeNode{Class: class, IsLeaf: true}
+		reports, err := loader.TrainModel(*inputFile, targetCols, *outputFile, trainOpts, derive, *minAccuracy)
+		if err != nil {
+			fail(err)
+			return
 		}
-	}
-
-	bestAttr := BestAttribute(dataset, header)
-	if bestAttr == "" {
-		// If no good split is found, return the most common class
-		mostCommonClass := ""
-		maxCount := 0
-		for class, count := range classCounts {
-			if count > maxCount {
-				maxCount = count
-				mostCommonClass = class
+		for i, report := range reports {
+			prefix := ""
+			if len(reports) > 1 {
+				prefix = targetCols[i] + "."
 			}
+			addReportMetrics("train."+prefix, report)
 		}
-		return &TreeNode{Class: mostCommonClass, IsLeaf: true}
-	}
+		runResult.Outputs = outputPaths(*outputFile, targetCols)
 
-	attrIndex := -1
-	for i, col := range header {
-		if col == bestAttr {
-			attrIndex = i
-			break
+	case "predict":
+		if *inputFile == "" || *modelFile == "" || *outputFile == "" {
+			usage("Usage: dt -c predict -i <test.csv> -m <model.dt> -o <predictions.csv> [-costs <costs.csv>] [-min-confidence 0.7 [-reject-label REVIEW]] [-contributions] [-summary]")
+			return
 		}
-	}
-
-	node := &TreeNode{Attribute: bestAttr, Children: make(map[string]*TreeNode)}
-
-	// Determine whether the attribute is numeric or categorical
-	switch dataset[0][attrIndex].(type) {
-	case string:
-		// Categorical split
-		splitted := SplitDataset(dataset, header, bestAttr)
-		for attrValue, subset := range splitted {
-			node.Children[attrValue] = BuildDecisionTree(subset, header)
+		costs, err := loadCosts(*costFile)
+		if err != nil {
+			fail(err)
+			return
 		}
-	default:
-		// Numeric split (find threshold)
-		threshold, leftSubset, rightSubset := FindBestThreshold(dataset, attrIndex)
-		node.Threshold = threshold
-		node.Children[fmt.Sprintf("<=%.2f", threshold)] = BuildDecisionTree(leftSubset, header)
-		node.Children[fmt.Sprintf(">%.2f", threshold)] = BuildDecisionTree(rightSubset, header)
-	}
-
-	return node
-}
-
-// Train decision tree and save model
-func TrainModel(inputFile, targetCol, outputFile string) error {
-	// Load dataset
-	header, dataset, _, err := LoadCsv(inputFile) // Ignoring colTypes
-	if err != nil {
-		return err
-	}
-
-	// Train decision tree
-	tree := BuildDecisionTree(dataset, header)
-
-	// Save model as JSON
-	modelFile, err := os.Create(outputFile)
-	if err != nil {
-		return fmt.Errorf("Error creating model file: %v", err)
-	}
-	defer modelFile.Close()
-
-	encoder := json.NewEncoder(modelFile)
-	err = encoder.Encode(tree)
-	if err != nil {
-		return fmt.Errorf("Error writing model: %v", err)
-	}
-
-	fmt.Println("Model saved to", outputFile)
-	return nil
-}
-
-// Load model from JSON file
-func LoadModel(modelFile string) (*TreeNode, error) {
-	file, err := os.Open(modelFile)
-	if err != nil {
-		return nil, fmt.Errorf("Error opening model file: %v", err)
-	}
-	defer file.Close()
-
-	var tree TreeNode
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&tree)
-	if err != nil {
-		return nil, fmt.Errorf("Error decoding model file: %v", err)
-	}
-
-	return &tree, nil
-}
-
-// Predict a single instance
-func Predict(node *TreeNode, instance map[string]string) string {
-	if node.IsLeaf {
-		return node.Class
-	}
-
-	attrValue, exists := instance[node.Attribute]
-	if !exists {
-		return "Unknown"
-	}
-
-	// If value exists, navigate tree
-	if child, found := node.Children[attrValue]; found {
-		return Predict(child, instance)
-	}
-
-	// Fallback: If unseen value, return majority class
-	return FindMostCommonClass(node)
-}
-
-func FindMostCommonClass(node *TreeNode) string {
-	classCount := make(map[string]int)
-
-	for _, child := range node.Children {
-		if child.IsLeaf {
-			classCount[child.Class]++
-		} else {
-			classCount[FindMostCommonClass(child)]++
+		abstain := dtree.Abstention{MinConfidence: *minConfidence, Label: *rejectLabel}
+		if err := checkAbstention(abstain); err != nil {
+			fail(err)
+			return
 		}
-	}
-
-	// Find most frequent class
-	var mostCommonClass string
-	maxCount := 0
-	for class, count := range classCount {
-		if count > maxCount {
-			mostCommonClass = class
-			maxCount = count
+		err = loader.PredictFromModel(*inputFile, *modelFile, *outputFile, *contributions, *summary, costs, abstain)
+		if err != nil {
+			fail(err)
+			return
 		}
-	}
-	return mostCommonClass
-}
-
-
-// Predict from test CSV using trained model
-func PredictFromModel(inputFile, modelFile, outputFile string) error {
-	// Load dataset
-	header, dataset, _, err := LoadCsv(inputFile) // Ignoring colTypes
-	if err != nil {
-		return err
-	}
-
-	// Load model
-	tree, err := LoadModel(modelFile)
-	if err != nil {
-		return err
-	}
-
-	// Open output file
-	outFile, err := os.Create(outputFile)
-	if err != nil {
-		return fmt.Errorf("Error creating output file: %v", err)
-	}
-	defer outFile.Close()
+		runResult.Outputs = []string{*outputFile}
 
-	writer := csv.NewWriter(outFile)
-	defer writer.Flush()
+	case "evaluate":
+		if *inputFile == "" || *modelFile == "" || len(targetCols) == 0 {
+			usage("Usage: dt -c evaluate -i <labelled.csv> -m <model.dt> -t <target> [-t <target2>...] [-o <report.json>] [-weight-column <col>] [-costs <costs.csv>] [-min-confidence 0.7] [-slice-by <col,col> [-min-support 10]] [-confusion-out <matrix.csv|.html>] [-min-accuracy 0.8]")
+			return
+		}
+		costs, err := loadCosts(*costFile)
+		if err != nil {
+			fail(err)
+			return
+		}
+		abstain := dtree.Abstention{MinConfidence: *minConfidence, Label: *rejectLabel}
+		if err := checkAbstention(abstain); err != nil {
+			fail(err)
+			return
+		}
+		evalOpts := dtree.EvalOptions{WeightColumn: *weightCol, Costs: costs, Abstain: abstain, MinSupport: *minSupport, ConfusionFile: *confusionFile}
+		if *sliceBy != "" {
+			for _, col := range strings.Split(*sliceBy, ",") {
+				evalOpts.SliceBy = append(evalOpts.SliceBy, strings.TrimSpace(col))
+			}
+		}
+		reports, err := loader.EvaluateModel(*inputFile, *modelFile, targetCols, *outputFile, evalOpts)
+		if err != nil {
+			fail(err)
+			return
+		}
+		for i, report := range reports {
+			prefix := ""
+			if len(reports) > 1 {
+				prefix = targetCols[i] + "."
+			}
+			addReportMetrics(prefix, report)
+		}
+		if *outputFile != "" {
+			runResult.Outputs = outputPaths(*outputFile, targetCols)
+		}
+		if *confusionFile != "" {
+			runResult.Outputs = append(runResult.Outputs, outputPaths(*confusionFile, targetCols)...)
+		}
+		for i, report := range reports {
+			if err := dtree.CheckAccuracy(targetCols[i], report, *minAccuracy); err != nil {
+				fail(err)
+				return
+			}
+		}
 
-	// Write header with "Prediction" column
-	newHeader := append(header, "Prediction")
-	writer.Write(newHeader)
+	case "cv":
+		if *inputFile == "" || len(targetCols) > 1 {
+			usage("Usage: dt -c cv -i <input.csv> [-t <target>] [-k 5] [-workers 0] [-seed 1] [-save-folds <dir>] [training flags as for train]")
+			return
+		}
+		target := ""
+		if len(targetCols) == 1 {
+			target = targetCols[0]
+		}
+		trainOpts, err := buildTrainOpts()
+		if err != nil {
+			fail(err)
+			return
+		}
+		results, err := loader.CrossValidate(*inputFile, target, *folds, *workers, *seed, *foldDir, trainOpts, derive)
+		if err != nil {
+			fail(err)
+			return
+		}
+		dtree.PrintCrossValidation(results)
+		runResult.Metrics = dtree.CrossValidationMetrics(results)
+		for _, r := range results {
+			if r.ModelFile != "" {
+				runResult.Outputs = append(runResult.Outputs, r.ModelFile, r.PredictionsFile)
+			}
+		}
+		if *foldDir != "" {
+			fmt.Println("Fold models and predictions saved to", *foldDir)
+		}
 
-	// Predict for each row
-	for _, row := range dataset {
-		instance := make(map[string]string)
-		for i, value := range row {
-			instance[header[i]] = fmt.Sprintf("%v", value) // Convert to string
+	case "errors":
+		if *inputFile == "" || *modelFile == "" || len(targetCols) != 1 {
+			usage("Usage: dt -c errors -i <labelled.csv> -m <model.dt> -t <target> [-top 50]")
+			return
+		}
+		misclassified, rows, err := loader.ListErrors(*inputFile, *modelFile, targetCols[0], *top)
+		if err != nil {
+			fail(err)
+			return
 		}
+		runResult.Metrics = map[string]float64{"misclassified": float64(misclassified), "rows": float64(rows)}
 
-		prediction := Predict(tree, instance)
-		newRow := append(interfaceSliceToStringSlice(row), prediction)
-		writer.Write(newRow)
-	}
-	fmt.Println("Predictions saved to", outputFile)
-	return nil
-}
+	case "compare":
+		if *inputFile == "" || *modelFile == "" || *modelFile2 == "" || len(targetCols) != 1 {
+			usage("Usage: dt -c compare -i <labelled.csv> -m <a.dt> -m2 <b.dt> -t <target>")
+			return
+		}
+		comparison, err := loader.CompareModels(*inputFile, *modelFile, *modelFile2, targetCols[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		addReportMetrics("a.", comparison.A)
+		addReportMetrics("b.", comparison.B)
+		agreement := comparison.Agreement
+		runResult.Metrics["only_a_right"] = float64(agreement.OnlyA)
+		runResult.Metrics["only_b_right"] = float64(agreement.OnlyB)
+		runResult.Metrics["disagreements"] = float64(agreement.Disagree)
+		runResult.Metrics["mcnemar_p_value"] = agreement.PValue
+
+	case "counterfactual":
+		if *modelFile == "" || *instanceJSON == "" || *desiredClass == "" {
+			usage(`Usage: dt -c counterfactual -m <model.dt> -json '{"Outlook":"Sunny",...}' -target <class>`)
+			return
+		}
+		err := loader.CounterfactualFromModel(*modelFile, *instanceJSON, *desiredClass)
+		if err != nil {
+			fail(err)
+		}
 
-// Convert interface{} slice to string slice
-func interfaceSliceToStringSlice(row []interface{}) []string {
-	result := make([]string, len(row))
-	for i, val := range row {
-		result[i] = fmt.Sprintf("%v", val)
-	}
-	return result
-}
+	case "inspect":
+		if *modelFile == "" {
+			usage(`Usage: dt -c inspect -m <model.dt> [-leaf N | -json '{"Outlook":"Sunny",...}']`)
+			return
+		}
+		err := loader.InspectModel(*modelFile, *leaf, *instanceJSON)
+		if err != nil {
+			fail(err)
+		}
 
-func main() {
-	// Define CLI flags
-	command := flag.String("c", "", "Command: train or predict")
-	inputFile := flag.String("i", "", "Input CSV file")
-	targetCol := flag.String("t", "", "Target column (only for training)")
-	modelFile := flag.String("m", "", "Model file (only for prediction)")
-	outputFile := flag.String("o", "", "Output file")
+	case "join":
+		if *leftFile == "" || *rightFile == "" || *joinOn == "" || *outputFile == "" {
+			usage("Usage: dt -c join -left <features.csv> -right <labels.csv> -on <key> -o <merged.csv> [-how inner|left] [-sorted]")
+			return
+		}
+		opts := dtree.JoinOptions{Left: *leftFile, Right: *rightFile, On: *joinOn, How: *joinHow, Sorted: *sorted}
+		err := dtree.JoinCSV(opts, *outputFile)
+		if err != nil {
+			fail(err)
+			return
+		}
+		runResult.Inputs = []string{*leftFile, *rightFile}
+		runResult.Outputs = []string{*outputFile}
 
-	// Parse flags
-	flag.Parse()
+	case "aggregate":
+		if *inputFile == "" || *groupBy == "" || *aggSpec == "" || *outputFile == "" {
+			usage("Usage: dt -c aggregate -i <events.csv> -groupby <key,...> -agg \"count(*), mean(amount)\" -o <table.csv>")
+			return
+		}
+		err := loader.AggregateCSV(*inputFile, *groupBy, *aggSpec, *outputFile)
+		if err != nil {
+			fail(err)
+			return
+		}
+		runResult.Outputs = []string{*outputFile}
 
-	// Execute command
-	switch *command {
-	case "train":
-		if *inputFile == "" || *targetCol == "" || *outputFile == "" {
-			fmt.Println("Usage: dt -c train -i <input.csv> -t <target> -o <model.dt>")
+	case "shard":
+		if *inputFile == "" || *parts == 0 {
+			usage("Usage: dt -c shard -i <big.csv> -parts <N> [-o <shard.csv>]")
 			return
 		}
-		err := TrainModel(*inputFile, *targetCol, *outputFile)
+		paths, err := dtree.ShardCSV(*inputFile, *parts, *outputFile)
 		if err != nil {
-			fmt.Println("Error:", err)
+			fail(err)
+			return
 		}
+		runResult.Outputs = paths
 
-	case "predict":
-		if *inputFile == "" || *modelFile == "" || *outputFile == "" {
-			fmt.Println("Usage: dt -c predict -i <test.csv> -m <model.dt> -o <predictions.csv>")
+	case "merge-models":
+		if len(args) == 0 || *outputFile == "" {
+			usage("Usage: dt -c merge-models <part1.dt> <part2.dt>... -o <forest.dt>")
 			return
 		}
-		err := PredictFromModel(*inputFile, *modelFile, *outputFile)
+		err := loader.MergeModels(args, *outputFile)
 		if err != nil {
-			fmt.Println("Error:", err)
+			fail(err)
+			return
 		}
+		runResult.Inputs = args
+		runResult.Outputs = []string{*outputFile}
 
 	default:
-		fmt.Println("Invalid command. Use 'train' or 'predict'.")
+		fmt.Println("Invalid command. Use 'train', 'predict', 'evaluate', 'cv', 'errors', 'compare', 'counterfactual', 'inspect', 'join', 'aggregate', 'shard' or 'merge-models'.")
+		runResult.Error = fmt.Sprintf("invalid command %q", *command)
+		runResult.ExitCode = dtree.ExitUsage
 	}
 }
 
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"machineLearning/dtree"
+	"machineLearning/metrics"
+)
+
+// RunResult is the single JSON object dt prints with -json-output
+type RunResult struct {
+	Command         string             `json:"command"`
+	OK              bool               `json:"ok"`
+	Error           string             `json:"error,omitempty"`
+	ExitCode        int                `json:"exitCode"`
+	Inputs          []string           `json:"inputs,omitempty"`
+	Outputs         []string           `json:"outputs,omitempty"`
+	Metrics         map[string]float64 `json:"metrics,omitempty"`
+	Warnings        []string           `json:"warnings,omitempty"`
+	DurationSeconds float64            `json:"durationSeconds"`
+}
+
+// runResult collects the outcome of the command being run
+var runResult = &RunResult{}
+
+// fail reports a command error, printing it as every command does and recording it
+func fail(err error) {
+	fmt.Println("Error:", err)
+	runResult.Error = err.Error()
+	runResult.ExitCode = dtree.ExitCode(err)
+}
+
+// usage prints a command's usage line and records the missing arguments as the error
+func usage(line string) {
+	fmt.Println(line)
+	runResult.Error = "missing arguments; " + line
+	runResult.ExitCode = dtree.ExitUsage
+}
+
+// reportWarnings prints what the command warned about and records it in the result
+func reportWarnings(loader *dtree.Loader) {
+	runResult.Warnings = loader.Warnings()
+	for _, warning := range runResult.Warnings {
+		fmt.Println("Warning:", warning)
+	}
+}
+
+// addReportMetrics copies a report's headline metrics into the result, prefixed with the
+// target name when several targets were evaluated
+func addReportMetrics(prefix string, report *metrics.Report) {
+	if runResult.Metrics == nil {
+		runResult.Metrics = make(map[string]float64)
+	}
+	for name, value := range report.Metrics {
+		runResult.Metrics[prefix+name] = value
+	}
+}
+
+// silenceStdout sends everything commands print to the null device and returns a function
+// that restores stdout. -quiet and -json-output use it so that the progress and report
+// lines printed throughout the package stay out of scripted output.
+func silenceStdout() (func(), error) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %v", os.DevNull, err)
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	return func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}, nil
+}
+
+// finishRun completes the result and writes it: as a JSON object with -json-output, or as
+// a single error line on stderr with -quiet
+func finishRun(start time.Time, jsonOutput bool) {
+	runResult.OK = runResult.Error == ""
+	runResult.DurationSeconds = time.Since(start).Seconds()
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(runResult)
+		return
+	}
+	if !runResult.OK {
+		fmt.Fprintln(os.Stderr, "Error:", runResult.Error)
+	}
+}
+
+// outputPaths lists the files a command wrote to path, one per target when there are several
+func outputPaths(path string, targets []string) []string {
+	if len(targets) <= 1 {
+		return []string{path}
+	}
+	paths := make([]string, len(targets))
+	for i, target := range targets {
+		paths[i] = dtree.TargetPath(path, target)
+	}
+	return paths
+}
@@ -0,0 +1,69 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"machineLearning/dtree"
+)
+
+// The browser build exposes two functions on the global object:
+//
+//	dtLoadModel(modelJSON)  loads a model saved by `dt -c train`; returns an error message or null.
+//	                        Models with -derive columns are rejected.
+//	dtPredict({col: value}) predicts one instance with the loaded model
+//
+// Build with GOOS=js GOARCH=wasm go build -o dt.wasm and load it with wasm_exec.js.
+var (
+	wasmTree  *dtree.TreeNode
+	wasmModel *dtree.CompiledTree
+)
+
+func main() {
+	js.Global().Set("dtLoadModel", js.FuncOf(jsLoadModel))
+	js.Global().Set("dtPredict", js.FuncOf(jsPredict))
+	select {} // keep the functions alive for the lifetime of the page
+}
+
+func jsLoadModel(this js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return "dtLoadModel expects the model JSON as a string"
+	}
+	var tree dtree.TreeNode
+	if err := json.Unmarshal([]byte(args[0].String()), &tree); err != nil {
+		return "error decoding model: " + err.Error()
+	}
+	if len(tree.Members) > 0 {
+		return "ensemble models are not supported in the browser"
+	}
+	if _, err := dtree.PrepareInstance(&tree, nil); err != nil {
+		return err.Error()
+	}
+	wasmTree, wasmModel = &tree, dtree.Compile(&tree)
+	return nil
+}
+
+func jsPredict(this js.Value, args []js.Value) any {
+	if wasmModel == nil {
+		return "Unknown"
+	}
+	if len(args) != 1 || args[0].Type() != js.TypeObject {
+		return "Unknown"
+	}
+
+	instance := make(map[string]string)
+	keys := js.Global().Get("Object").Call("keys", args[0])
+	for i := 0; i < keys.Length(); i++ {
+		key := keys.Index(i).String()
+		// Convert through JS String() so numbers arrive as "25" rather than "<number: 25>"
+		instance[key] = js.Global().Call("String", args[0].Get(key)).String()
+	}
+	// Repeat the aliases, missing-value tokens and normalization the model was trained with
+	prepared, err := dtree.PrepareInstance(wasmTree, instance)
+	if err != nil {
+		return "Unknown"
+	}
+	return wasmModel.Predict(prepared)
+}
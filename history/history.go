@@ -0,0 +1,93 @@
+// Package history records the per-epoch training curve of the gradient-trained models and
+// writes it out for plotting.
+package history
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Epoch records the losses and accuracies of one training epoch
+type Epoch struct {
+	Epoch              int     `json:"epoch"`
+	TrainLoss          float64 `json:"trainLoss"`
+	TrainAccuracy      float64 `json:"trainAccuracy"`
+	ValidationLoss     float64 `json:"validationLoss"`
+	ValidationAccuracy float64 `json:"validationAccuracy"`
+	// HasValidation is set when a validation split was scored, since a loss of 0 is a real result
+	HasValidation bool `json:"hasValidation,omitempty"`
+}
+
+// Best returns the epoch with the lowest validation loss, or the last one when no
+// validation split was used
+func Best(epochs []Epoch) Epoch {
+	best := epochs[len(epochs)-1]
+	for _, h := range epochs {
+		if h.HasValidation && (!best.HasValidation || h.ValidationLoss < best.ValidationLoss) {
+			best = h
+		}
+	}
+	return best
+}
+
+// Summarize prints how many epochs were trained and which one was best
+func Summarize(epochs []Epoch) {
+	if len(epochs) == 0 {
+		return
+	}
+	best := Best(epochs)
+	if !best.HasValidation {
+		fmt.Printf("Epochs trained: %d, no validation split\n", len(epochs))
+		return
+	}
+	fmt.Printf("Epochs trained: %d, best validation loss %.4f at epoch %d\n",
+		len(epochs), best.ValidationLoss, best.Epoch)
+}
+
+// Save writes the history as CSV to outputFile, or to standard output when it is empty
+func Save(epochs []Epoch, outputFile string) error {
+	var out io.Writer = os.Stdout
+	if outputFile != "" {
+		file, err := os.Create(outputFile)
+		if err != nil {
+			return fmt.Errorf("error creating output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := WriteCSV(out, epochs); err != nil {
+		return err
+	}
+	if outputFile != "" {
+		fmt.Println("History saved to", outputFile)
+	}
+	return nil
+}
+
+// WriteCSV writes one CSV row per epoch, leaving the validation columns empty when no
+// validation split was scored
+func WriteCSV(out io.Writer, epochs []Epoch) error {
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"epoch", "train_loss", "train_accuracy", "validation_loss", "validation_accuracy"})
+	for _, h := range epochs {
+		record := []string{
+			strconv.Itoa(h.Epoch),
+			strconv.FormatFloat(h.TrainLoss, 'f', 6, 64),
+			strconv.FormatFloat(h.TrainAccuracy, 'f', 6, 64),
+			"", "",
+		}
+		if h.HasValidation {
+			record[3] = strconv.FormatFloat(h.ValidationLoss, 'f', 6, 64)
+			record[4] = strconv.FormatFloat(h.ValidationAccuracy, 'f', 6, 64)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error writing history: %v", err)
+	}
+	return nil
+}
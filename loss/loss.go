@@ -0,0 +1,105 @@
+// Package loss holds the loss functions shared by the gradient-trained models,
+// each paired with its derivative with respect to the model output.
+package loss
+
+import "math"
+
+// minProbability keeps log-loss finite when a model assigns zero probability to the true class
+const minProbability = 1e-12
+
+// CrossEntropy returns the negative log-probability of the true class
+func CrossEntropy(probs []float64, label int) float64 {
+	return -math.Log(math.Max(probs[label], minProbability))
+}
+
+// SoftmaxCrossEntropyGradient returns the gradient of the cross-entropy with respect to the
+// logits of a softmax output, which simplifies to probs - onehot(label)
+func SoftmaxCrossEntropyGradient(probs []float64, label int) []float64 {
+	grad := make([]float64, len(probs))
+	copy(grad, probs)
+	grad[label] -= 1
+	return grad
+}
+
+// Hinge returns max(0, 1 - y*score) for a label y of +1 or -1
+func Hinge(score, y float64) float64 {
+	return math.Max(0, 1-y*score)
+}
+
+// HingeGradient returns the sub-gradient of the hinge loss with respect to the score
+func HingeGradient(score, y float64) float64 {
+	if y*score < 1 {
+		return -y
+	}
+	return 0
+}
+
+// SquaredError returns half the squared difference, so its gradient is simply pred - target
+func SquaredError(pred, target float64) float64 {
+	d := pred - target
+	return 0.5 * d * d
+}
+
+// SquaredErrorGradient returns the derivative of SquaredError with respect to pred
+func SquaredErrorGradient(pred, target float64) float64 {
+	return pred - target
+}
+
+// AbsoluteError returns |pred - target|
+func AbsoluteError(pred, target float64) float64 {
+	return math.Abs(pred - target)
+}
+
+// AbsoluteErrorGradient returns the sub-gradient of AbsoluteError with respect to pred
+func AbsoluteErrorGradient(pred, target float64) float64 {
+	switch {
+	case pred > target:
+		return 1
+	case pred < target:
+		return -1
+	}
+	return 0
+}
+
+// Huber is quadratic for errors up to delta and linear beyond, limiting the pull of outliers
+func Huber(pred, target, delta float64) float64 {
+	d := math.Abs(pred - target)
+	if d <= delta {
+		return 0.5 * d * d
+	}
+	return delta * (d - 0.5*delta)
+}
+
+// HuberGradient returns the derivative of Huber with respect to pred
+func HuberGradient(pred, target, delta float64) float64 {
+	d := pred - target
+	if math.Abs(d) <= delta {
+		return d
+	}
+	return delta * AbsoluteErrorGradient(pred, target)
+}
+
+// MSE returns the mean squared error over paired predictions and targets
+func MSE(preds, targets []float64) float64 {
+	if len(preds) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for i := range preds {
+		d := preds[i] - targets[i]
+		sum += d * d
+	}
+	return sum / float64(len(preds))
+}
+
+// MAE returns the mean absolute error over paired predictions and targets
+func MAE(preds, targets []float64) float64 {
+	if len(preds) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for i := range preds {
+		sum += AbsoluteError(preds[i], targets[i])
+	}
+	return sum / float64(len(preds))
+}
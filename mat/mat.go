@@ -0,0 +1,180 @@
+// Package mat provides the small amount of linear algebra the models need:
+// dense row-major matrices, compressed sparse row (CSR) matrices for wide one-hot
+// data, matrix-vector products and transposes.
+package mat
+
+import "fmt"
+
+// Dot returns the inner product of two equal-length vectors
+func Dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// Dense is a row-major matrix
+type Dense struct {
+	Rows, Cols int
+	Data       []float64
+}
+
+// NewDense returns a zero matrix of the given shape
+func NewDense(rows, cols int) *Dense {
+	return &Dense{Rows: rows, Cols: cols, Data: make([]float64, rows*cols)}
+}
+
+// DenseFromRows copies a slice of equal-length rows into a matrix
+func DenseFromRows(rows [][]float64) (*Dense, error) {
+	if len(rows) == 0 {
+		return NewDense(0, 0), nil
+	}
+	m := NewDense(len(rows), len(rows[0]))
+	for i, row := range rows {
+		if len(row) != m.Cols {
+			return nil, fmt.Errorf("row %d has %d columns, expected %d", i, len(row), m.Cols)
+		}
+		copy(m.Data[i*m.Cols:], row)
+	}
+	return m, nil
+}
+
+// At returns the value at row i, column j
+func (m *Dense) At(i, j int) float64 {
+	return m.Data[i*m.Cols+j]
+}
+
+// Set stores v at row i, column j
+func (m *Dense) Set(i, j int, v float64) {
+	m.Data[i*m.Cols+j] = v
+}
+
+// Row returns row i as a slice sharing the matrix storage
+func (m *Dense) Row(i int) []float64 {
+	return m.Data[i*m.Cols : (i+1)*m.Cols]
+}
+
+// MulVec returns m·x
+func (m *Dense) MulVec(x []float64) []float64 {
+	out := make([]float64, m.Rows)
+	for i := range out {
+		out[i] = Dot(m.Row(i), x)
+	}
+	return out
+}
+
+// Mul returns the matrix product m·other
+func (m *Dense) Mul(other *Dense) (*Dense, error) {
+	if m.Cols != other.Rows {
+		return nil, fmt.Errorf("cannot multiply %dx%d by %dx%d", m.Rows, m.Cols, other.Rows, other.Cols)
+	}
+	out := NewDense(m.Rows, other.Cols)
+	for i := 0; i < m.Rows; i++ {
+		for k := 0; k < m.Cols; k++ {
+			a := m.At(i, k)
+			if a == 0 {
+				continue
+			}
+			for j := 0; j < other.Cols; j++ {
+				out.Data[i*out.Cols+j] += a * other.At(k, j)
+			}
+		}
+	}
+	return out, nil
+}
+
+// T returns the transpose of m as a new matrix
+func (m *Dense) T() *Dense {
+	out := NewDense(m.Cols, m.Rows)
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			out.Set(j, i, m.At(i, j))
+		}
+	}
+	return out
+}
+
+// CSR is a compressed sparse row matrix: the non-zeros of row i are
+// Values[RowPtr[i]:RowPtr[i+1]] at columns ColIdx[RowPtr[i]:RowPtr[i+1]]
+type CSR struct {
+	Rows, Cols int
+	RowPtr     []int
+	ColIdx     []int
+	Values     []float64
+}
+
+// CSRFromDense keeps only the non-zero entries of a dense matrix
+func CSRFromDense(m *Dense) *CSR {
+	s := &CSR{Rows: m.Rows, Cols: m.Cols, RowPtr: make([]int, m.Rows+1)}
+	for i := 0; i < m.Rows; i++ {
+		for j, v := range m.Row(i) {
+			if v != 0 {
+				s.ColIdx = append(s.ColIdx, j)
+				s.Values = append(s.Values, v)
+			}
+		}
+		s.RowPtr[i+1] = len(s.Values)
+	}
+	return s
+}
+
+// NNZ returns the number of stored non-zero entries
+func (s *CSR) NNZ() int {
+	return len(s.Values)
+}
+
+// MulVec returns s·x, touching only the stored entries
+func (s *CSR) MulVec(x []float64) []float64 {
+	out := make([]float64, s.Rows)
+	for i := 0; i < s.Rows; i++ {
+		for k := s.RowPtr[i]; k < s.RowPtr[i+1]; k++ {
+			out[i] += s.Values[k] * x[s.ColIdx[k]]
+		}
+	}
+	return out
+}
+
+// RowDot returns the inner product of row i with a dense vector
+func (s *CSR) RowDot(i int, x []float64) float64 {
+	sum := 0.0
+	for k := s.RowPtr[i]; k < s.RowPtr[i+1]; k++ {
+		sum += s.Values[k] * x[s.ColIdx[k]]
+	}
+	return sum
+}
+
+// T returns the transpose as a new CSR matrix
+func (s *CSR) T() *CSR {
+	out := &CSR{Rows: s.Cols, Cols: s.Rows, RowPtr: make([]int, s.Cols+1)}
+	for _, j := range s.ColIdx {
+		out.RowPtr[j+1]++
+	}
+	for j := 0; j < s.Cols; j++ {
+		out.RowPtr[j+1] += out.RowPtr[j]
+	}
+
+	out.ColIdx = make([]int, len(s.ColIdx))
+	out.Values = make([]float64, len(s.Values))
+	next := append([]int{}, out.RowPtr[:s.Cols]...)
+	for i := 0; i < s.Rows; i++ {
+		for k := s.RowPtr[i]; k < s.RowPtr[i+1]; k++ {
+			j := s.ColIdx[k]
+			out.ColIdx[next[j]] = i
+			out.Values[next[j]] = s.Values[k]
+			next[j]++
+		}
+	}
+	return out
+}
+
+// ToDense expands the sparse matrix into a dense one
+func (s *CSR) ToDense() *Dense {
+	m := NewDense(s.Rows, s.Cols)
+	for i := 0; i < s.Rows; i++ {
+		for k := s.RowPtr[i]; k < s.RowPtr[i+1]; k++ {
+			m.Set(i, s.ColIdx[k], s.Values[k])
+		}
+	}
+	return m
+}
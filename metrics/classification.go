@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ClassMetrics holds the one-vs-rest scores of a single class
+type ClassMetrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"`
+}
+
+// ConfusionMatrix counts predictions by true label (rows) and predicted label (columns)
+type ConfusionMatrix struct {
+	Labels []string `json:"labels"`
+	Counts [][]int  `json:"counts"`
+}
+
+// NewConfusionMatrix builds the matrix over every label seen in either slice, sorted by name
+func NewConfusionMatrix(actual, predicted []string) *ConfusionMatrix {
+	seen := make(map[string]bool)
+	for i := range actual {
+		seen[actual[i]] = true
+		seen[predicted[i]] = true
+	}
+	labels := make([]string, 0, len(seen))
+	for label := range seen {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	index := make(map[string]int, len(labels))
+	for i, label := range labels {
+		index[label] = i
+	}
+
+	cm := &ConfusionMatrix{Labels: labels, Counts: make([][]int, len(labels))}
+	for i := range cm.Counts {
+		cm.Counts[i] = make([]int, len(labels))
+	}
+	for i := range actual {
+		cm.Counts[index[actual[i]]][index[predicted[i]]]++
+	}
+	return cm
+}
+
+// rowSums returns the number of rows whose true label is each class
+func (cm *ConfusionMatrix) rowSums() []int {
+	sums := make([]int, len(cm.Labels))
+	for i, row := range cm.Counts {
+		for _, c := range row {
+			sums[i] += c
+		}
+	}
+	return sums
+}
+
+// colSums returns the number of rows predicted as each class
+func (cm *ConfusionMatrix) colSums() []int {
+	sums := make([]int, len(cm.Labels))
+	for _, row := range cm.Counts {
+		for j, c := range row {
+			sums[j] += c
+		}
+	}
+	return sums
+}
+
+// Classification scores predicted labels against the true labels. Besides accuracy it reports
+// precision, recall and F1 averaged three ways — macro (every class counts equally), micro
+// (every row counts equally) and weighted (classes weighted by support) — along with Cohen's
+// kappa and the Matthews correlation coefficient, which stay informative on imbalanced data.
+func Classification(actual, predicted []string) (*Report, error) {
+	if len(actual) != len(predicted) {
+		return nil, fmt.Errorf("got %d predictions for %d labels", len(predicted), len(actual))
+	}
+	if len(actual) == 0 {
+		return nil, fmt.Errorf("no predictions to evaluate")
+	}
+
+	cm := NewConfusionMatrix(actual, predicted)
+	n := float64(len(actual))
+	trueCounts, predCounts := cm.rowSums(), cm.colSums()
+
+	report := &Report{
+		Kind:      "classification",
+		Samples:   len(actual),
+		Metrics:   make(map[string]float64),
+		Classes:   make(map[string]ClassMetrics),
+		Confusion: cm,
+	}
+
+	correct := 0.0
+	var macroP, macroR, macroF, weightedP, weightedR, weightedF float64
+	for i, label := range cm.Labels {
+		tp := float64(cm.Counts[i][i])
+		correct += tp
+
+		cls := ClassMetrics{Support: trueCounts[i]}
+		if predCounts[i] > 0 {
+			cls.Precision = tp / float64(predCounts[i])
+		}
+		if trueCounts[i] > 0 {
+			cls.Recall = tp / float64(trueCounts[i])
+		}
+		if cls.Precision+cls.Recall > 0 {
+			cls.F1 = 2 * cls.Precision * cls.Recall / (cls.Precision + cls.Recall)
+		}
+		report.Classes[label] = cls
+
+		macroP += cls.Precision
+		macroR += cls.Recall
+		macroF += cls.F1
+		w := float64(cls.Support) / n
+		weightedP += w * cls.Precision
+		weightedR += w * cls.Recall
+		weightedF += w * cls.F1
+	}
+
+	k := float64(len(cm.Labels))
+	accuracy := correct / n
+	report.Metrics["accuracy"] = accuracy
+	report.Metrics["precision_macro"] = macroP / k
+	report.Metrics["recall_macro"] = macroR / k
+	report.Metrics["f1_macro"] = macroF / k
+	// With exactly one label per row, micro-averaged precision, recall and F1 all equal accuracy
+	report.Metrics["precision_micro"] = accuracy
+	report.Metrics["recall_micro"] = accuracy
+	report.Metrics["f1_micro"] = accuracy
+	report.Metrics["precision_weighted"] = weightedP
+	report.Metrics["recall_weighted"] = weightedR
+	report.Metrics["f1_weighted"] = weightedF
+	report.Metrics["cohen_kappa"] = CohenKappa(cm)
+	report.Metrics["mcc"] = MatthewsCorrelation(cm)
+
+	return report, nil
+}
+
+// CohenKappa returns the agreement between truth and prediction corrected for the agreement
+// expected by chance from the marginal class frequencies
+func CohenKappa(cm *ConfusionMatrix) float64 {
+	trueCounts, predCounts := cm.rowSums(), cm.colSums()
+	n, observed, expected := 0.0, 0.0, 0.0
+	for i := range cm.Labels {
+		n += float64(trueCounts[i])
+		observed += float64(cm.Counts[i][i])
+	}
+	if n == 0 {
+		return 0
+	}
+	for i := range cm.Labels {
+		expected += float64(trueCounts[i]) * float64(predCounts[i]) / (n * n)
+	}
+	observed /= n
+	if expected == 1 {
+		return 0
+	}
+	return (observed - expected) / (1 - expected)
+}
+
+// MatthewsCorrelation returns the multiclass Matthews correlation coefficient (Gorodkin's R_K)
+func MatthewsCorrelation(cm *ConfusionMatrix) float64 {
+	trueCounts, predCounts := cm.rowSums(), cm.colSums()
+	var s, c, sumPT, sumPP, sumTT float64
+	for i := range cm.Labels {
+		s += float64(trueCounts[i])
+		c += float64(cm.Counts[i][i])
+		sumPT += float64(predCounts[i]) * float64(trueCounts[i])
+		sumPP += float64(predCounts[i]) * float64(predCounts[i])
+		sumTT += float64(trueCounts[i]) * float64(trueCounts[i])
+	}
+	denom := math.Sqrt((s*s - sumPP) * (s*s - sumTT))
+	if denom == 0 {
+		return 0
+	}
+	return (c*s - sumPT) / denom
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Export writes the confusion matrix to filename: as an HTML heat map when the name ends in
+// .html or .htm, and as a CSV table otherwise. With cost set, each cell holds its count times
+// the cost of predicting the column's label for a row of the row's label, so the most
+// expensive mistakes stand out rather than the most frequent ones.
+func (cm *ConfusionMatrix) Export(filename string, cost func(actual, predicted string) float64) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	cells := cm.cells(cost)
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm":
+		err = cm.writeHTML(file, cells, cost != nil)
+	default:
+		err = cm.writeCSV(file, cells)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", filename, err)
+	}
+	return nil
+}
+
+// cells returns the counts, multiplied by their cost when cost is set
+func (cm *ConfusionMatrix) cells(cost func(actual, predicted string) float64) [][]float64 {
+	cells := make([][]float64, len(cm.Counts))
+	for i, row := range cm.Counts {
+		cells[i] = make([]float64, len(row))
+		for j, c := range row {
+			cells[i][j] = float64(c)
+			if cost != nil {
+				cells[i][j] *= cost(cm.Labels[i], cm.Labels[j])
+			}
+		}
+	}
+	return cells
+}
+
+// writeCSV writes one row per actual label, with a column per predicted label
+func (cm *ConfusionMatrix) writeCSV(w io.Writer, cells [][]float64) error {
+	writer := csv.NewWriter(w)
+	writer.Write(append([]string{"actual\\predicted"}, cm.Labels...))
+	for i, row := range cells {
+		record := []string{cm.Labels[i]}
+		for _, v := range row {
+			record = append(record, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// heatMapCell is one shaded cell of the HTML heat map
+type heatMapCell struct {
+	Value string
+	Shade float64 // 0 for the smallest value up to 1 for the largest
+}
+
+var heatMapTemplate = template.Must(template.New("confusion").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 6px 12px; text-align: right; }
+th { background: #f4f4f4; }
+</style>
+</head>
+<body>
+<h2>{{.Title}}</h2>
+<table>
+<tr><th>actual \ predicted</th>{{range .Labels}}<th>{{.}}</th>{{end}}</tr>
+{{range $i, $row := .Rows}}<tr><th>{{index $.Labels $i}}</th>{{range $row}}<td style="background: rgba(31, 119, 180, {{printf "%.2f" .Shade}}){{if gt .Shade 0.5}}; color: white{{end}}">{{.Value}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// writeHTML writes the matrix as a table whose cells are shaded by value
+func (cm *ConfusionMatrix) writeHTML(w io.Writer, cells [][]float64, costWeighted bool) error {
+	largest := 0.0
+	for _, row := range cells {
+		for _, v := range row {
+			largest = max(largest, v)
+		}
+	}
+
+	rows := make([][]heatMapCell, len(cells))
+	for i, row := range cells {
+		rows[i] = make([]heatMapCell, len(row))
+		for j, v := range row {
+			rows[i][j].Value = strconv.FormatFloat(v, 'f', -1, 64)
+			if largest > 0 {
+				rows[i][j].Shade = v / largest
+			}
+		}
+	}
+
+	title := "Confusion matrix"
+	if costWeighted {
+		title = "Cost-weighted confusion matrix"
+	}
+	return heatMapTemplate.Execute(w, struct {
+		Title  string
+		Labels []string
+		Rows   [][]heatMapCell
+	}{title, cm.Labels, rows})
+}
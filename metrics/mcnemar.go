@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+)
+
+// exactMcNemarLimit is the number of discordant rows below which McNemar's test uses the
+// exact binomial distribution instead of the chi-squared approximation
+const exactMcNemarLimit = 25
+
+// Agreement is the contingency table of two models' correctness on the same rows, with
+// McNemar's test of whether their error rates differ. Only the discordant cells, OnlyA and
+// OnlyB, carry evidence: rows both models get right or wrong say nothing about which is better.
+type Agreement struct {
+	BothRight int `json:"bothRight"`
+	OnlyA     int `json:"onlyA"` // A right, B wrong
+	OnlyB     int `json:"onlyB"` // B right, A wrong
+	BothWrong int `json:"bothWrong"`
+	// Disagree counts the rows where the predictions differ, including rows both get wrong
+	// with different labels
+	Disagree int     `json:"disagree"`
+	PValue   float64 `json:"pValue"`
+	Exact    bool    `json:"exact"` // PValue comes from the exact binomial test
+}
+
+// CompareModels tabulates where two models' predictions of the same rows are right and runs
+// McNemar's test on the table. Below 25 discordant rows the two-sided p-value is exact,
+// otherwise it uses the continuity-corrected chi-squared statistic.
+func CompareModels(actual, predictedA, predictedB []string) (*Agreement, error) {
+	return CompareModelLabels(actual, predictedA, actual, predictedB)
+}
+
+// CompareModelLabels is CompareModels for models that read the labels of the same rows
+// differently, for example one lower-cases them: each model is judged against its own labels
+func CompareModelLabels(actualA, predictedA, actualB, predictedB []string) (*Agreement, error) {
+	if len(actualA) != len(predictedA) || len(actualB) != len(predictedB) || len(actualA) != len(actualB) {
+		return nil, fmt.Errorf("error comparing models: %d and %d labels but %d and %d predictions", len(actualA), len(actualB), len(predictedA), len(predictedB))
+	}
+	if len(actualA) == 0 {
+		return nil, fmt.Errorf("error comparing models: no rows")
+	}
+
+	a := &Agreement{}
+	for i := range actualA {
+		rightA, rightB := predictedA[i] == actualA[i], predictedB[i] == actualB[i]
+		switch {
+		case rightA && rightB:
+			a.BothRight++
+		case rightA:
+			a.OnlyA++
+		case rightB:
+			a.OnlyB++
+		default:
+			a.BothWrong++
+		}
+		if predictedA[i] != predictedB[i] {
+			a.Disagree++
+		}
+	}
+	a.PValue, a.Exact = mcNemarPValue(a.OnlyA, a.OnlyB)
+	return a, nil
+}
+
+// mcNemarPValue returns the two-sided p-value of McNemar's test for b and c discordant rows
+// and whether it is exact
+func mcNemarPValue(b, c int) (float64, bool) {
+	n := b + c
+	if n == 0 {
+		return 1, true
+	}
+	if n < exactMcNemarLimit {
+		// Twice the lower tail of Binomial(n, 0.5), summed in log space to avoid overflow
+		k := b
+		if c < k {
+			k = c
+		}
+		lgammaN1, _ := math.Lgamma(float64(n + 1))
+		tail := 0.0
+		for i := 0; i <= k; i++ {
+			lgammaI, _ := math.Lgamma(float64(i + 1))
+			lgammaNI, _ := math.Lgamma(float64(n - i + 1))
+			tail += math.Exp(lgammaN1 - lgammaI - lgammaNI - float64(n)*math.Ln2)
+		}
+		return math.Min(1, 2*tail), true
+	}
+	// The continuity correction never takes the difference below zero
+	diff := math.Max(0, math.Abs(float64(b-c))-1)
+	chi2 := diff * diff / float64(n)
+	// The chi-squared survival function with one degree of freedom
+	return math.Erfc(math.Sqrt(chi2 / 2)), false
+}
+
+// Print writes the contingency table and the test result to stdout, naming the models
+// nameA and nameB
+func (a *Agreement) Print(nameA, nameB string) {
+	total := a.BothRight + a.OnlyA + a.OnlyB + a.BothWrong
+	fmt.Printf("Agreement over %d rows (%d predicted differently)\n", total, a.Disagree)
+	fmt.Printf("  %-12s %10s %10s\n", "", nameB+" right", nameB+" wrong")
+	fmt.Printf("  %-12s %10d %10d\n", nameA+" right", a.BothRight, a.OnlyA)
+	fmt.Printf("  %-12s %10d %10d\n", nameA+" wrong", a.OnlyB, a.BothWrong)
+
+	method := "chi-squared, continuity corrected"
+	if a.Exact {
+		method = "exact binomial"
+	}
+	fmt.Printf("\nMcNemar's test (%s): p = %.4g\n", method, a.PValue)
+	switch {
+	case a.PValue >= 0.05:
+		fmt.Println("No significant difference between the models at the 0.05 level")
+	case a.OnlyA > a.OnlyB:
+		fmt.Printf("%s is significantly more accurate (p < 0.05)\n", nameA)
+	default:
+		fmt.Printf("%s is significantly more accurate (p < 0.05)\n", nameB)
+	}
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ThresholdMetrics holds the scores of one class when it is only predicted for rows whose
+// probability for that class reaches the threshold
+type ThresholdMetrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	Predicted int     `json:"predicted"`
+}
+
+// ThresholdReport holds the per-class scores at one probability threshold
+type ThresholdReport struct {
+	Threshold float64                     `json:"threshold"`
+	Classes   map[string]ThresholdMetrics `json:"classes"`
+}
+
+// TopKAccuracy returns the fraction of rows whose true class is among the k most probable.
+// probs[i][c] is the probability of classes[c] for row i.
+func TopKAccuracy(classes, actual []string, probs [][]float64, k int) float64 {
+	if len(actual) == 0 {
+		return 0
+	}
+	hits := 0
+	for i, row := range probs {
+		order := make([]int, len(row))
+		for c := range order {
+			order[c] = c
+		}
+		sort.SliceStable(order, func(a, b int) bool { return row[order[a]] > row[order[b]] })
+
+		for _, c := range order[:min(k, len(order))] {
+			if classes[c] == actual[i] {
+				hits++
+				break
+			}
+		}
+	}
+	return float64(hits) / float64(len(actual))
+}
+
+// PrecisionAtThreshold treats every class as its own binary decision "probability >= threshold"
+// and returns the precision and recall of each, as used when acting on any sufficiently likely class
+func PrecisionAtThreshold(classes, actual []string, probs [][]float64, threshold float64) ThresholdReport {
+	report := ThresholdReport{Threshold: threshold, Classes: make(map[string]ThresholdMetrics)}
+	for c, class := range classes {
+		tp, predicted, support := 0, 0, 0
+		for i, row := range probs {
+			isClass := actual[i] == class
+			if isClass {
+				support++
+			}
+			if row[c] >= threshold {
+				predicted++
+				if isClass {
+					tp++
+				}
+			}
+		}
+
+		m := ThresholdMetrics{Predicted: predicted}
+		if predicted > 0 {
+			m.Precision = float64(tp) / float64(predicted)
+		}
+		if support > 0 {
+			m.Recall = float64(tp) / float64(support)
+		}
+		report.Classes[class] = m
+	}
+	return report
+}
+
+// AddProbabilityMetrics extends a classification report with top-k accuracies (as
+// "top_<k>_accuracy") and per-class precision at each probability threshold
+func (r *Report) AddProbabilityMetrics(classes, actual []string, probs [][]float64, ks []int, thresholds []float64) error {
+	if len(probs) != len(actual) {
+		return fmt.Errorf("got %d probability rows for %d labels", len(probs), len(actual))
+	}
+	for _, k := range ks {
+		if k < 1 {
+			return fmt.Errorf("top-k must be at least 1, got %d", k)
+		}
+		r.Metrics[fmt.Sprintf("top_%d_accuracy", k)] = TopKAccuracy(classes, actual, probs, k)
+	}
+	for _, t := range thresholds {
+		r.Thresholds = append(r.Thresholds, PrecisionAtThreshold(classes, actual, probs, t))
+	}
+	return nil
+}
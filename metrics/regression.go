@@ -0,0 +1,109 @@
+// Package metrics scores model predictions against known targets and assembles the
+// results into reports that serialise to the same JSON shape for every kind of model.
+package metrics
+
+import (
+	"fmt"
+	"math"
+
+	"machineLearning/loss"
+)
+
+// RMSE returns the root mean squared error
+func RMSE(preds, targets []float64) float64 {
+	return math.Sqrt(loss.MSE(preds, targets))
+}
+
+// MAE returns the mean absolute error
+func MAE(preds, targets []float64) float64 {
+	return loss.MAE(preds, targets)
+}
+
+// MAPE returns the mean absolute percentage error, skipping rows whose target is zero
+func MAPE(preds, targets []float64) float64 {
+	sum, n := 0.0, 0
+	for i := range preds {
+		if targets[i] == 0 {
+			continue
+		}
+		sum += math.Abs((targets[i] - preds[i]) / targets[i])
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return 100 * sum / float64(n)
+}
+
+// R2 returns the coefficient of determination, 1 - SS_res / SS_tot
+func R2(preds, targets []float64) float64 {
+	mean := meanOf(targets)
+	ssRes, ssTot := 0.0, 0.0
+	for i := range preds {
+		ssRes += (targets[i] - preds[i]) * (targets[i] - preds[i])
+		ssTot += (targets[i] - mean) * (targets[i] - mean)
+	}
+	if ssTot == 0 {
+		return 0
+	}
+	return 1 - ssRes/ssTot
+}
+
+// ExplainedVariance returns 1 - Var(target - pred) / Var(target). Unlike R², it ignores a
+// constant bias in the predictions.
+func ExplainedVariance(preds, targets []float64) float64 {
+	residuals := make([]float64, len(preds))
+	for i := range preds {
+		residuals[i] = targets[i] - preds[i]
+	}
+	varTarget := varianceOf(targets)
+	if varTarget == 0 {
+		return 0
+	}
+	return 1 - varianceOf(residuals)/varTarget
+}
+
+// Regression scores numeric predictions and returns a report with every regression metric
+func Regression(preds, targets []float64) (*Report, error) {
+	if len(preds) != len(targets) {
+		return nil, fmt.Errorf("got %d predictions for %d targets", len(preds), len(targets))
+	}
+	if len(preds) == 0 {
+		return nil, fmt.Errorf("no predictions to evaluate")
+	}
+
+	return &Report{
+		Kind:    "regression",
+		Samples: len(preds),
+		Metrics: map[string]float64{
+			"rmse":               RMSE(preds, targets),
+			"mae":                MAE(preds, targets),
+			"mape":               MAPE(preds, targets),
+			"r2":                 R2(preds, targets),
+			"explained_variance": ExplainedVariance(preds, targets),
+		},
+	}, nil
+}
+
+func meanOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func varianceOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	mean := meanOf(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Report is the JSON-serialisable result of an evaluation. Classes and Confusion are only
+// filled in for classification, and Thresholds only for models that output probabilities.
+type Report struct {
+	Kind       string                  `json:"kind"`
+	Samples    int                     `json:"samples"`
+	Metrics    map[string]float64      `json:"metrics"`
+	Classes    map[string]ClassMetrics `json:"classes,omitempty"`
+	Confusion  *ConfusionMatrix        `json:"confusion,omitempty"`
+	Thresholds []ThresholdReport       `json:"thresholds,omitempty"`
+	// WeightColumn and WeightedClasses are only filled in by AddCaseWeights
+	WeightColumn    string                          `json:"weightColumn,omitempty"`
+	WeightedClasses map[string]WeightedClassMetrics `json:"weightedClasses,omitempty"`
+	// Slices is only filled in by AddSlices
+	Slices []SliceMetrics `json:"slices,omitempty"`
+}
+
+// Print writes the report to stdout as aligned tables
+func (r *Report) Print() {
+	fmt.Printf("%s report over %d samples\n", r.Kind, r.Samples)
+
+	names := make([]string, 0, len(r.Metrics))
+	for name := range r.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-30s %.4f\n", name, r.Metrics[name])
+	}
+
+	if len(r.Classes) > 0 {
+		labels := make([]string, 0, len(r.Classes))
+		for label := range r.Classes {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+
+		fmt.Printf("\n  %-15s %10s %10s %10s %8s\n", "class", "precision", "recall", "f1", "support")
+		for _, label := range labels {
+			c := r.Classes[label]
+			fmt.Printf("  %-15s %10.4f %10.4f %10.4f %8d\n", label, c.Precision, c.Recall, c.F1, c.Support)
+		}
+	}
+
+	if len(r.WeightedClasses) > 0 {
+		labels := make([]string, 0, len(r.WeightedClasses))
+		for label := range r.WeightedClasses {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+
+		fmt.Printf("\n  weighted by %s\n", r.WeightColumn)
+		fmt.Printf("  %-15s %10s %10s %12s\n", "class", "precision", "recall", "weight")
+		for _, label := range labels {
+			c := r.WeightedClasses[label]
+			fmt.Printf("  %-15s %10.4f %10.4f %12.2f\n", label, c.Precision, c.Recall, c.Weight)
+		}
+	}
+
+	for i, s := range r.Slices {
+		if i == 0 || s.Column != r.Slices[i-1].Column {
+			fmt.Printf("\n  by %s\n", s.Column)
+			fmt.Printf("  %-15s %10s %10s %10s %8s\n", "value", "accuracy", "vs all", "f1", "support")
+		}
+		fmt.Printf("  %-15s %10.4f %+10.4f %10.4f %8d\n", s.Value, s.Accuracy, s.Accuracy-r.Metrics["accuracy"], s.F1Macro, s.Support)
+	}
+
+	for _, t := range r.Thresholds {
+		labels := make([]string, 0, len(t.Classes))
+		for label := range t.Classes {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+
+		fmt.Printf("\n  at probability >= %.2f\n", t.Threshold)
+		fmt.Printf("  %-15s %10s %10s %10s\n", "class", "precision", "recall", "predicted")
+		for _, label := range labels {
+			c := t.Classes[label]
+			fmt.Printf("  %-15s %10.4f %10.4f %10d\n", label, c.Precision, c.Recall, c.Predicted)
+		}
+	}
+
+	if r.Confusion != nil {
+		fmt.Printf("\n  confusion (rows: actual, columns: predicted)\n  %-15s", "")
+		for _, label := range r.Confusion.Labels {
+			fmt.Printf(" %10s", label)
+		}
+		fmt.Println()
+		for i, row := range r.Confusion.Counts {
+			fmt.Printf("  %-15s", r.Confusion.Labels[i])
+			for _, c := range row {
+				fmt.Printf(" %10d", c)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// Save writes the report as indented JSON
+func (r *Report) Save(filename string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding report: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("error writing report: %v", err)
+	}
+	return nil
+}
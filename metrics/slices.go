@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SliceMetrics scores the rows sharing one value of a column
+type SliceMetrics struct {
+	Column   string  `json:"column"`
+	Value    string  `json:"value"`
+	Support  int     `json:"support"`
+	Accuracy float64 `json:"accuracy"`
+	F1Macro  float64 `json:"f1Macro"`
+}
+
+// AddSlices extends a classification report with the accuracy and macro F1 of each value of
+// a column, e.g. every Region, so segments where the model underperforms stand out. values
+// holds the column's value for each row. Values with fewer than minSupport rows are left
+// out, as their scores would be mostly noise. Slices are appended worst accuracy first.
+func (r *Report) AddSlices(column string, values, actual, predicted []string, minSupport int) error {
+	if len(actual) != len(predicted) || len(values) != len(actual) {
+		return fmt.Errorf("got %d %s values for %d labels and %d predictions", len(values), column, len(actual), len(predicted))
+	}
+
+	rows := make(map[string][]int)
+	for i, v := range values {
+		rows[v] = append(rows[v], i)
+	}
+
+	var slices []SliceMetrics
+	for value, idx := range rows {
+		if len(idx) < minSupport {
+			continue
+		}
+		sliceActual := make([]string, len(idx))
+		slicePredicted := make([]string, len(idx))
+		for j, i := range idx {
+			sliceActual[j], slicePredicted[j] = actual[i], predicted[i]
+		}
+		report, err := Classification(sliceActual, slicePredicted)
+		if err != nil {
+			return fmt.Errorf("slice %s = %s: %v", column, value, err)
+		}
+		slices = append(slices, SliceMetrics{
+			Column:   column,
+			Value:    value,
+			Support:  len(idx),
+			Accuracy: report.Metrics["accuracy"],
+			F1Macro:  report.Metrics["f1_macro"],
+		})
+	}
+	sort.Slice(slices, func(a, b int) bool {
+		if slices[a].Accuracy != slices[b].Accuracy {
+			return slices[a].Accuracy < slices[b].Accuracy
+		}
+		return slices[a].Value < slices[b].Value
+	})
+	r.Slices = append(r.Slices, slices...)
+	return nil
+}
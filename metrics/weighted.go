@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WeightedClassMetrics holds one class's scores when every row counts by its weight
+type WeightedClassMetrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	Weight    float64 `json:"weight"` // total weight of the rows whose true class this is
+}
+
+// AddCaseWeights extends a classification report with metrics that count every row by its
+// weight, such as the revenue at stake, instead of once: "case_weighted_accuracy", macro
+// averaged "case_weighted_precision_macro", "case_weighted_recall_macro" and
+// "case_weighted_f1_macro", "total_weight", and per-class precision and recall. column names
+// the weights in the report.
+func (r *Report) AddCaseWeights(column string, actual, predicted []string, weights []float64) error {
+	if len(actual) != len(predicted) || len(weights) != len(actual) {
+		return fmt.Errorf("got %d weights for %d labels and %d predictions", len(weights), len(actual), len(predicted))
+	}
+
+	var total, correct float64
+	trueWeight := make(map[string]float64)
+	predWeight := make(map[string]float64)
+	hitWeight := make(map[string]float64)
+	for i, w := range weights {
+		if w < 0 {
+			return fmt.Errorf("row %d has negative weight %v", i, w)
+		}
+		total += w
+		trueWeight[actual[i]] += w
+		predWeight[predicted[i]] += w
+		if actual[i] == predicted[i] {
+			correct += w
+			hitWeight[actual[i]] += w
+		}
+	}
+	if total == 0 {
+		return fmt.Errorf("the weights in %s sum to zero", column)
+	}
+
+	labels := make([]string, 0, len(trueWeight)+len(predWeight))
+	for label := range trueWeight {
+		labels = append(labels, label)
+	}
+	for label := range predWeight {
+		if _, ok := trueWeight[label]; !ok {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	r.WeightColumn = column
+	r.WeightedClasses = make(map[string]WeightedClassMetrics, len(labels))
+	var macroP, macroR, macroF float64
+	for _, label := range labels {
+		cls := WeightedClassMetrics{Weight: trueWeight[label]}
+		if predWeight[label] > 0 {
+			cls.Precision = hitWeight[label] / predWeight[label]
+		}
+		if trueWeight[label] > 0 {
+			cls.Recall = hitWeight[label] / trueWeight[label]
+		}
+		r.WeightedClasses[label] = cls
+
+		macroP += cls.Precision
+		macroR += cls.Recall
+		if cls.Precision+cls.Recall > 0 {
+			macroF += 2 * cls.Precision * cls.Recall / (cls.Precision + cls.Recall)
+		}
+	}
+
+	k := float64(len(labels))
+	r.Metrics["case_weighted_accuracy"] = correct / total
+	r.Metrics["case_weighted_precision_macro"] = macroP / k
+	r.Metrics["case_weighted_recall_macro"] = macroR / k
+	r.Metrics["case_weighted_f1_macro"] = macroF / k
+	r.Metrics["total_weight"] = total
+	return nil
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"machineLearning/history"
+	"machineLearning/metrics"
+	"machineLearning/tabular"
+)
+
+// Model is the envelope saved to disk: the feature encoding, the class labels and the weights
+type Model struct {
+	Type     string            `json:"type"`
+	Target   string            `json:"target"`
+	Classes  []string          `json:"classes"`
+	Features []tabular.Feature `json:"features"`
+	Network  *Network          `json:"network"`
+	History  []history.Epoch   `json:"history,omitempty"`
+}
+
+// Train the network and save the model envelope as JSON
+func TrainModel(inputFile, targetCol, outputFile string, cfg TrainConfig) error {
+	header, rows, err := tabular.LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+
+	targetIndex := tabular.ColumnIndex(header, targetCol)
+	if targetIndex == -1 {
+		return fmt.Errorf("target column %q not found in %s", targetCol, inputFile)
+	}
+
+	model := &Model{Type: "mlp", Target: targetCol, Features: tabular.BuildFeatures(header, rows, targetIndex)}
+	colIndex, err := tabular.FeatureColumns(model.Features, header)
+	if err != nil {
+		return err
+	}
+
+	classIndex := make(map[string]int)
+	X := make([][]float64, len(rows))
+	Y := make([]int, len(rows))
+	for i, row := range rows {
+		label := row[targetIndex]
+		if _, ok := classIndex[label]; !ok {
+			classIndex[label] = len(model.Classes)
+			model.Classes = append(model.Classes, label)
+		}
+		X[i] = tabular.EncodeRow(model.Features, colIndex, row)
+		Y[i] = classIndex[label]
+	}
+
+	net, epochs, err := TrainNetwork(X, Y, len(model.Classes), cfg)
+	if err != nil {
+		return err
+	}
+	model.Network = net
+	model.History = epochs
+
+	last := epochs[len(epochs)-1]
+	fmt.Printf("Trained %d epochs: train loss %.4f, train accuracy %.4f\n", last.Epoch, last.TrainLoss, last.TrainAccuracy)
+
+	modelFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating model file: %v", err)
+	}
+	defer modelFile.Close()
+
+	encoder := json.NewEncoder(modelFile)
+	if err := encoder.Encode(model); err != nil {
+		return fmt.Errorf("error writing model: %v", err)
+	}
+
+	fmt.Println("Model saved to", outputFile)
+	return nil
+}
+
+// LoadModel reads a model envelope from a JSON file
+func LoadModel(modelFile string) (*Model, error) {
+	file, err := os.Open(modelFile)
+	if err != nil {
+		return nil, fmt.Errorf("error opening model file: %v", err)
+	}
+	defer file.Close()
+
+	var model Model
+	if err := json.NewDecoder(file).Decode(&model); err != nil {
+		return nil, fmt.Errorf("error decoding model file: %v", err)
+	}
+	if model.Network == nil {
+		return nil, fmt.Errorf("model file %s has no network weights", modelFile)
+	}
+	return &model, nil
+}
+
+// InspectModel prints a summary of a saved model, or with showHistory set, dumps its
+// per-epoch training curve as CSV to outputFile (standard output when empty) for plotting
+func InspectModel(modelFile string, showHistory bool, outputFile string) error {
+	model, err := LoadModel(modelFile)
+	if err != nil {
+		return err
+	}
+
+	if showHistory {
+		if len(model.History) == 0 {
+			return fmt.Errorf("model %s has no training history", modelFile)
+		}
+		return history.Save(model.History, outputFile)
+	}
+
+	fmt.Println("Type:", model.Type)
+	fmt.Println("Target:", model.Target)
+	fmt.Println("Classes:", model.Classes)
+	fmt.Println("Features:", len(model.Features))
+	for _, f := range model.Features {
+		fmt.Printf("  %s (%s)\n", f.Name, f.Type)
+	}
+	history.Summarize(model.History)
+	return nil
+}
+
+// PredictFromModel scores a CSV file, appending the predicted class and one probability column per class
+func PredictFromModel(inputFile, modelFile, outputFile string) error {
+	header, rows, err := tabular.LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+
+	model, err := LoadModel(modelFile)
+	if err != nil {
+		return err
+	}
+
+	colIndex, err := tabular.FeatureColumns(model.Features, header)
+	if err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	defer writer.Flush()
+
+	newHeader := append(append([]string{}, header...), "Prediction")
+	for _, class := range model.Classes {
+		newHeader = append(newHeader, "P_"+class)
+	}
+	writer.Write(newHeader)
+
+	for _, row := range rows {
+		probs := model.Network.Predict(tabular.EncodeRow(model.Features, colIndex, row))
+		newRow := append(append([]string{}, row...), model.Classes[argmax(probs)])
+		for _, p := range probs {
+			newRow = append(newRow, strconv.FormatFloat(p, 'f', 6, 64))
+		}
+		writer.Write(newRow)
+	}
+
+	fmt.Println("Predictions saved to", outputFile)
+	return nil
+}
+
+// EvaluateModel scores a labelled CSV, printing a classification report extended with top-k
+// accuracy and per-class precision at the given probability thresholds
+func EvaluateModel(inputFile, modelFile, targetCol, reportFile string, ks []int, thresholds []float64) error {
+	header, rows, err := tabular.LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+
+	model, err := LoadModel(modelFile)
+	if err != nil {
+		return err
+	}
+	if targetCol == "" {
+		targetCol = model.Target
+	}
+
+	targetIndex := tabular.ColumnIndex(header, targetCol)
+	if targetIndex == -1 {
+		return fmt.Errorf("target column %q not found in %s", targetCol, inputFile)
+	}
+	colIndex, err := tabular.FeatureColumns(model.Features, header)
+	if err != nil {
+		return err
+	}
+
+	actual := make([]string, len(rows))
+	predicted := make([]string, len(rows))
+	probs := make([][]float64, len(rows))
+	for i, row := range rows {
+		probs[i] = model.Network.Predict(tabular.EncodeRow(model.Features, colIndex, row))
+		predicted[i] = model.Classes[argmax(probs[i])]
+		actual[i] = row[targetIndex]
+	}
+
+	report, err := metrics.Classification(actual, predicted)
+	if err != nil {
+		return err
+	}
+	if err := report.AddProbabilityMetrics(model.Classes, actual, probs, ks, thresholds); err != nil {
+		return err
+	}
+	report.Print()
+
+	if reportFile != "" {
+		if err := report.Save(reportFile); err != nil {
+			return err
+		}
+		fmt.Println("Report saved to", reportFile)
+	}
+	return nil
+}
+
+// parseInts parses a comma-separated list of integers such as "1,3"
+func parseInts(value string) ([]int, error) {
+	var values []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", part)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// parseFloats parses a comma-separated list of numbers such as "0.5,0.9"
+func parseFloats(value string) ([]float64, error) {
+	var values []float64
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", part)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// parseHidden parses a comma-separated list of hidden layer sizes such as "16,8"
+func parseHidden(value string) ([]int, error) {
+	var sizes []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		size, err := strconv.Atoi(part)
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid hidden layer size %q", part)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+func main() {
+	// Define CLI flags
+	command := flag.String("c", "", "Command: train, predict, evaluate or inspect")
+	inputFile := flag.String("i", "", "Input CSV file")
+	targetCol := flag.String("t", "", "Target column (for training; evaluation defaults to the model's)")
+	modelFile := flag.String("m", "", "Model file (for prediction and evaluation)")
+	outputFile := flag.String("o", "", "Output file")
+	showHistory := flag.Bool("history", false, "Dump the per-epoch training history as CSV (inspect)")
+	hidden := flag.String("hidden", "16", "Comma-separated hidden layer sizes, e.g. 16,8")
+	activation := flag.String("activation", "relu", "Hidden activation: relu or sigmoid")
+	optimizer := flag.String("optimizer", "momentum", "Optimizer: sgd, momentum or adam")
+	learningRate := flag.Float64("lr", 0.05, "Learning rate")
+	momentum := flag.Float64("momentum", 0.9, "Momentum coefficient (momentum optimizer)")
+	batchSize := flag.Int("batch-size", 16, "Mini-batch size")
+	epochs := flag.Int("epochs", 200, "Maximum number of epochs")
+	valSplit := flag.Float64("val-split", 0.2, "Fraction of rows held out for early stopping")
+	patience := flag.Int("patience", 20, "Stop after this many epochs without validation improvement")
+	seed := flag.Int64("seed", 1, "Random seed")
+	topK := flag.String("top-k", "1,2", "Comma-separated k values for top-k accuracy (evaluate)")
+	thresholds := flag.String("thresholds", "", "Comma-separated probability thresholds for per-class precision (evaluate)")
+
+	// Parse flags
+	flag.Parse()
+
+	// Execute command
+	switch *command {
+	case "train":
+		if *inputFile == "" || *targetCol == "" || *outputFile == "" {
+			fmt.Println("Usage: mlp -c train -i <input.csv> -t <target> -o <model.json> [-hidden 16,8] [-activation relu|sigmoid]")
+			return
+		}
+		sizes, err := parseHidden(*hidden)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		cfg := TrainConfig{
+			Hidden:          sizes,
+			Activation:      *activation,
+			Optimizer:       *optimizer,
+			LearningRate:    *learningRate,
+			Momentum:        *momentum,
+			BatchSize:       *batchSize,
+			Epochs:          *epochs,
+			ValidationSplit: *valSplit,
+			Patience:        *patience,
+			Seed:            *seed,
+		}
+		err = TrainModel(*inputFile, *targetCol, *outputFile, cfg)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	case "predict":
+		if *inputFile == "" || *modelFile == "" || *outputFile == "" {
+			fmt.Println("Usage: mlp -c predict -i <test.csv> -m <model.json> -o <predictions.csv>")
+			return
+		}
+		err := PredictFromModel(*inputFile, *modelFile, *outputFile)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	case "evaluate":
+		if *inputFile == "" || *modelFile == "" {
+			fmt.Println("Usage: mlp -c evaluate -i <labelled.csv> -m <model.json> [-t <target>] [-top-k 1,3] [-thresholds 0.5,0.9] [-o <report.json>]")
+			return
+		}
+		ks, err := parseInts(*topK)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		ts, err := parseFloats(*thresholds)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		err = EvaluateModel(*inputFile, *modelFile, *targetCol, *outputFile, ks, ts)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	case "inspect":
+		if *modelFile == "" {
+			fmt.Println("Usage: mlp -c inspect -m <model.json> [-history] [-o <history.csv>]")
+			return
+		}
+		err := InspectModel(*modelFile, *showHistory, *outputFile)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	default:
+		fmt.Println("Invalid command. Use 'train', 'predict', 'evaluate' or 'inspect'.")
+	}
+}
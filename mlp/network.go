@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"machineLearning/history"
+	"machineLearning/loss"
+	"machineLearning/mat"
+	"machineLearning/optim"
+)
+
+// Layer is a fully connected layer: Weights[j][i] connects input i to output j
+type Layer struct {
+	Weights [][]float64 `json:"weights"`
+	Biases  []float64   `json:"biases"`
+}
+
+// Network is a feed-forward classifier. Hidden layers share one activation and the
+// output layer is always a softmax over the classes.
+type Network struct {
+	Layers     []Layer `json:"layers"`
+	Activation string  `json:"activation"`
+}
+
+// TrainConfig holds the hyperparameters for mini-batch training
+type TrainConfig struct {
+	Hidden          []int
+	Activation      string
+	Optimizer       string
+	LearningRate    float64
+	Momentum        float64
+	BatchSize       int
+	Epochs          int
+	ValidationSplit float64
+	Patience        int
+	Seed            int64
+}
+
+// NewNetwork creates a network with the given layer sizes (inputs, hidden..., classes),
+// initialising weights with He scaling for ReLU and Xavier scaling for sigmoid
+func NewNetwork(sizes []int, activation string, rng *rand.Rand) (*Network, error) {
+	if activation != "relu" && activation != "sigmoid" {
+		return nil, fmt.Errorf("unknown activation %q (use relu or sigmoid)", activation)
+	}
+
+	net := &Network{Activation: activation}
+	for l := 1; l < len(sizes); l++ {
+		in, out := sizes[l-1], sizes[l]
+		scale := math.Sqrt(1.0 / float64(in))
+		if activation == "relu" {
+			scale = math.Sqrt(2.0 / float64(in))
+		}
+
+		layer := Layer{Weights: make([][]float64, out), Biases: make([]float64, out)}
+		for j := range layer.Weights {
+			layer.Weights[j] = make([]float64, in)
+			for i := range layer.Weights[j] {
+				layer.Weights[j][i] = rng.NormFloat64() * scale
+			}
+		}
+		net.Layers = append(net.Layers, layer)
+	}
+	return net, nil
+}
+
+// Forward returns the activations of every layer, starting with the input itself.
+// The last entry holds the softmax class probabilities.
+func (n *Network) Forward(x []float64) [][]float64 {
+	activations := [][]float64{x}
+	for l, layer := range n.Layers {
+		input := activations[len(activations)-1]
+		output := make([]float64, len(layer.Biases))
+		for j, weights := range layer.Weights {
+			output[j] = layer.Biases[j] + mat.Dot(weights, input)
+		}
+
+		if l == len(n.Layers)-1 {
+			softmax(output)
+		} else {
+			for j := range output {
+				output[j] = n.activate(output[j])
+			}
+		}
+		activations = append(activations, output)
+	}
+	return activations
+}
+
+// Predict returns the class probabilities for one encoded input
+func (n *Network) Predict(x []float64) []float64 {
+	activations := n.Forward(x)
+	return activations[len(activations)-1]
+}
+
+func (n *Network) activate(z float64) float64 {
+	if n.Activation == "relu" {
+		return math.Max(0, z)
+	}
+	return 1 / (1 + math.Exp(-z))
+}
+
+// activationDerivative returns the derivative of the activation given its output value
+func (n *Network) activationDerivative(a float64) float64 {
+	if n.Activation == "relu" {
+		if a > 0 {
+			return 1
+		}
+		return 0
+	}
+	return a * (1 - a)
+}
+
+// softmax converts scores into probabilities in place
+func softmax(scores []float64) {
+	maxScore := math.Inf(-1)
+	for _, s := range scores {
+		maxScore = math.Max(maxScore, s)
+	}
+	sum := 0.0
+	for i, s := range scores {
+		scores[i] = math.Exp(s - maxScore)
+		sum += scores[i]
+	}
+	for i := range scores {
+		scores[i] /= sum
+	}
+}
+
+// gradients accumulates the cross-entropy gradient of one sample into grads
+func (n *Network) gradients(x []float64, label int, grads []Layer) {
+	activations := n.Forward(x)
+
+	// Softmax with cross-entropy gives the simple output error p - y
+	delta := loss.SoftmaxCrossEntropyGradient(activations[len(activations)-1], label)
+
+	for l := len(n.Layers) - 1; l >= 0; l-- {
+		input := activations[l]
+		for j := range delta {
+			grads[l].Biases[j] += delta[j]
+			for i := range input {
+				grads[l].Weights[j][i] += delta[j] * input[i]
+			}
+		}
+		if l == 0 {
+			break
+		}
+
+		prev := make([]float64, len(input))
+		for i := range prev {
+			sum := 0.0
+			for j := range delta {
+				sum += n.Layers[l].Weights[j][i] * delta[j]
+			}
+			prev[i] = sum * n.activationDerivative(input[i])
+		}
+		delta = prev
+	}
+}
+
+// Evaluate returns the mean cross-entropy loss and the accuracy over a set of samples
+func (n *Network) Evaluate(X [][]float64, Y []int) (float64, float64) {
+	if len(X) == 0 {
+		return 0, 0
+	}
+	total, correct := 0.0, 0
+	for i, x := range X {
+		probs := n.Predict(x)
+		total += loss.CrossEntropy(probs, Y[i])
+		if argmax(probs) == Y[i] {
+			correct++
+		}
+	}
+	return total / float64(len(X)), float64(correct) / float64(len(X))
+}
+
+// TrainNetwork fits a network with mini-batch gradient descent using the configured optimizer
+// (sgd, momentum or adam). When a validation split is configured, training stops once the
+// validation loss has not improved for Patience epochs and the weights from the best epoch
+// are restored.
+func TrainNetwork(X [][]float64, Y []int, numClasses int, cfg TrainConfig) (*Network, []history.Epoch, error) {
+	if len(X) == 0 {
+		return nil, nil, fmt.Errorf("no training samples")
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	sizes := append(append([]int{len(X[0])}, cfg.Hidden...), numClasses)
+	net, err := NewNetwork(sizes, cfg.Activation, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	opt, err := optim.New(cfg.Optimizer, cfg.LearningRate, cfg.Momentum)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Hold out a shuffled validation split for early stopping
+	order := rng.Perm(len(X))
+	numVal := int(float64(len(X)) * cfg.ValidationSplit)
+	var trainX, valX [][]float64
+	var trainY, valY []int
+	for i, idx := range order {
+		if i < numVal {
+			valX, valY = append(valX, X[idx]), append(valY, Y[idx])
+		} else {
+			trainX, trainY = append(trainX, X[idx]), append(trainY, Y[idx])
+		}
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize < 1 || batchSize > len(trainX) {
+		batchSize = len(trainX)
+	}
+
+	best := cloneLayers(net.Layers)
+	bestLoss := math.Inf(1)
+	sinceBest := 0
+	var epochs []history.Epoch
+
+	for epoch := 1; epoch <= cfg.Epochs; epoch++ {
+		rng.Shuffle(len(trainX), func(i, j int) {
+			trainX[i], trainX[j] = trainX[j], trainX[i]
+			trainY[i], trainY[j] = trainY[j], trainY[i]
+		})
+
+		for start := 0; start < len(trainX); start += batchSize {
+			end := min(start+batchSize, len(trainX))
+			grads := zeroLayers(net.Layers)
+			for i := start; i < end; i++ {
+				net.gradients(trainX[i], trainY[i], grads)
+			}
+
+			// Each weight row and each bias vector is its own optimizer slot
+			slot := 0
+			batch := float64(end - start)
+			for l := range net.Layers {
+				for j := range net.Layers[l].Weights {
+					scaleValues(grads[l].Weights[j], 1/batch)
+					opt.Update(slot, net.Layers[l].Weights[j], grads[l].Weights[j])
+					slot++
+				}
+				scaleValues(grads[l].Biases, 1/batch)
+				opt.Update(slot, net.Layers[l].Biases, grads[l].Biases)
+				slot++
+			}
+		}
+
+		stats := history.Epoch{Epoch: epoch}
+		stats.TrainLoss, stats.TrainAccuracy = net.Evaluate(trainX, trainY)
+		if len(valX) > 0 {
+			stats.ValidationLoss, stats.ValidationAccuracy = net.Evaluate(valX, valY)
+			stats.HasValidation = true
+		}
+		epochs = append(epochs, stats)
+
+		if !stats.HasValidation {
+			continue
+		}
+		if stats.ValidationLoss < bestLoss {
+			bestLoss = stats.ValidationLoss
+			best = cloneLayers(net.Layers)
+			sinceBest = 0
+		} else if sinceBest++; cfg.Patience > 0 && sinceBest >= cfg.Patience {
+			fmt.Printf("Early stopping at epoch %d, best validation loss %.4f\n", epoch, bestLoss)
+			break
+		}
+	}
+
+	if len(valX) > 0 {
+		net.Layers = best
+	}
+	return net, epochs, nil
+}
+
+// zeroLayers returns layers shaped like the given ones with every value set to zero
+func zeroLayers(layers []Layer) []Layer {
+	zeros := make([]Layer, len(layers))
+	for l, layer := range layers {
+		zeros[l].Biases = make([]float64, len(layer.Biases))
+		zeros[l].Weights = make([][]float64, len(layer.Weights))
+		for j := range layer.Weights {
+			zeros[l].Weights[j] = make([]float64, len(layer.Weights[j]))
+		}
+	}
+	return zeros
+}
+
+// cloneLayers returns a deep copy of the given layers
+func cloneLayers(layers []Layer) []Layer {
+	clone := zeroLayers(layers)
+	for l, layer := range layers {
+		copy(clone[l].Biases, layer.Biases)
+		for j := range layer.Weights {
+			copy(clone[l].Weights[j], layer.Weights[j])
+		}
+	}
+	return clone
+}
+
+// scaleValues multiplies every value in place
+func scaleValues(values []float64, factor float64) {
+	for i := range values {
+		values[i] *= factor
+	}
+}
+
+// argmax returns the index of the largest value
+func argmax(values []float64) int {
+	best := 0
+	for i, v := range values {
+		if v > values[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,104 @@
+// Package optim implements the first-order optimizers used to fit model parameters.
+//
+// Parameters are updated in groups (for example one weight row of a layer). Each group is
+// identified by a slot number chosen by the caller, which lets stateful optimizers such as
+// momentum and Adam keep their running statistics between steps.
+package optim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Optimizer applies one update step to a group of parameters given their gradients
+type Optimizer interface {
+	Update(slot int, params, grads []float64)
+}
+
+// New returns the optimizer with the given name: "sgd", "momentum" or "adam"
+func New(name string, learningRate, momentum float64) (Optimizer, error) {
+	switch name {
+	case "sgd":
+		return &SGD{LearningRate: learningRate}, nil
+	case "momentum":
+		return NewMomentum(learningRate, momentum), nil
+	case "adam":
+		return NewAdam(learningRate), nil
+	}
+	return nil, fmt.Errorf("unknown optimizer %q (use sgd, momentum or adam)", name)
+}
+
+// SGD is plain stochastic gradient descent
+type SGD struct {
+	LearningRate float64
+}
+
+// Update moves every parameter against its gradient
+func (o *SGD) Update(slot int, params, grads []float64) {
+	for i := range params {
+		params[i] -= o.LearningRate * grads[i]
+	}
+}
+
+// Momentum is SGD with a velocity term that accumulates past gradients
+type Momentum struct {
+	LearningRate float64
+	Beta         float64
+	velocity     map[int][]float64
+}
+
+// NewMomentum returns a momentum optimizer; beta is the fraction of velocity kept each step
+func NewMomentum(learningRate, beta float64) *Momentum {
+	return &Momentum{LearningRate: learningRate, Beta: beta, velocity: make(map[int][]float64)}
+}
+
+// Update applies v = beta*v - lr*g followed by p += v
+func (o *Momentum) Update(slot int, params, grads []float64) {
+	v, ok := o.velocity[slot]
+	if !ok {
+		v = make([]float64, len(params))
+		o.velocity[slot] = v
+	}
+	for i := range params {
+		v[i] = o.Beta*v[i] - o.LearningRate*grads[i]
+		params[i] += v[i]
+	}
+}
+
+// Adam adapts the step size per parameter from running estimates of the gradient's
+// first and second moments, with bias correction for the early steps
+type Adam struct {
+	LearningRate float64
+	Beta1        float64
+	Beta2        float64
+	Epsilon      float64
+	state        map[int]*adamState
+}
+
+type adamState struct {
+	m, v []float64
+	t    int
+}
+
+// NewAdam returns an Adam optimizer with the usual defaults for the moment decay rates
+func NewAdam(learningRate float64) *Adam {
+	return &Adam{LearningRate: learningRate, Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8, state: make(map[int]*adamState)}
+}
+
+// Update applies one bias-corrected Adam step
+func (o *Adam) Update(slot int, params, grads []float64) {
+	s, ok := o.state[slot]
+	if !ok {
+		s = &adamState{m: make([]float64, len(params)), v: make([]float64, len(params))}
+		o.state[slot] = s
+	}
+	s.t++
+
+	c1 := 1 - math.Pow(o.Beta1, float64(s.t))
+	c2 := 1 - math.Pow(o.Beta2, float64(s.t))
+	for i := range params {
+		s.m[i] = o.Beta1*s.m[i] + (1-o.Beta1)*grads[i]
+		s.v[i] = o.Beta2*s.v[i] + (1-o.Beta2)*grads[i]*grads[i]
+		params[i] -= o.LearningRate * (s.m[i] / c1) / (math.Sqrt(s.v[i]/c2) + o.Epsilon)
+	}
+}
@@ -0,0 +1,98 @@
+package sketch
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// CountMin estimates how often each category occurs in a stream using a fixed grid of
+// counters. Estimates never undercount; with probability 1-delta they overcount by at most
+// epsilon times the total count.
+type CountMin struct {
+	Width  int        `json:"width"`
+	Depth  int        `json:"depth"`
+	Counts [][]uint64 `json:"counts"`
+	Total  uint64     `json:"total"`
+}
+
+// NewCountMin returns a sketch with depth rows of width counters
+func NewCountMin(width, depth int) (*CountMin, error) {
+	if width < 1 || depth < 1 {
+		return nil, fmt.Errorf("count-min width and depth must be positive, got %d and %d", width, depth)
+	}
+	counts := make([][]uint64, depth)
+	for i := range counts {
+		counts[i] = make([]uint64, width)
+	}
+	return &CountMin{Width: width, Depth: depth, Counts: counts}, nil
+}
+
+// NewCountMinWithError sizes a sketch for a relative error epsilon with failure probability delta
+func NewCountMinWithError(epsilon, delta float64) (*CountMin, error) {
+	if epsilon <= 0 || delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("count-min needs epsilon > 0 and 0 < delta < 1")
+	}
+	return NewCountMin(int(math.Ceil(math.E/epsilon)), int(math.Ceil(math.Log(1/delta))))
+}
+
+// Add records count occurrences of key
+func (c *CountMin) Add(key string, count uint64) {
+	h1, h2 := hashPair(key)
+	for row := range c.Counts {
+		c.Counts[row][c.column(h1, h2, row)] += count
+	}
+	c.Total += count
+}
+
+// Estimate returns the approximate number of occurrences of key
+func (c *CountMin) Estimate(key string) uint64 {
+	h1, h2 := hashPair(key)
+	estimate := uint64(math.MaxUint64)
+	for row := range c.Counts {
+		estimate = min(estimate, c.Counts[row][c.column(h1, h2, row)])
+	}
+	return estimate
+}
+
+// Frequency returns the estimated share of the stream taken by key
+func (c *CountMin) Frequency(key string) float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return float64(c.Estimate(key)) / float64(c.Total)
+}
+
+// IsRare reports whether key makes up less than minFraction of the stream, the test used to
+// fold rare categories into a shared "other" bucket. Because estimates only overcount, a
+// category reported as rare is certainly rare.
+func (c *CountMin) IsRare(key string, minFraction float64) bool {
+	return c.Frequency(key) < minFraction
+}
+
+// Merge adds the counts of another sketch with the same dimensions
+func (c *CountMin) Merge(other *CountMin) error {
+	if other.Width != c.Width || other.Depth != c.Depth {
+		return fmt.Errorf("cannot merge a %dx%d count-min sketch into a %dx%d one", other.Depth, other.Width, c.Depth, c.Width)
+	}
+	for row := range c.Counts {
+		for col := range c.Counts[row] {
+			c.Counts[row][col] += other.Counts[row][col]
+		}
+	}
+	c.Total += other.Total
+	return nil
+}
+
+// column picks the counter of key in one row by double hashing
+func (c *CountMin) column(h1, h2 uint64, row int) int {
+	return int((h1 + uint64(row)*h2) % uint64(c.Width))
+}
+
+// hashPair derives two independent-enough hashes of key from one FNV-1a hash
+func hashPair(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	return sum, (sum >> 32) | (sum << 32) | 1
+}
@@ -0,0 +1,170 @@
+// Package sketch provides fixed-memory summaries of data streams: a t-digest for numeric
+// quantiles and a count-min sketch for categorical frequencies.
+package sketch
+
+import (
+	"math"
+	"sort"
+)
+
+// Centroid is a cluster of nearby values summarised by their mean and count
+type Centroid struct {
+	Mean   float64 `json:"mean"`
+	Weight float64 `json:"weight"`
+}
+
+// TDigest estimates quantiles of a numeric stream in bounded memory. Centroids near the
+// tails are kept small, so extreme quantiles stay accurate while the middle is compressed.
+type TDigest struct {
+	Compression float64    `json:"compression"`
+	Centroids   []Centroid `json:"centroids"`
+	Count       float64    `json:"count"`
+	Min         float64    `json:"min"`
+	Max         float64    `json:"max"`
+
+	buffer []Centroid
+}
+
+// NewTDigest returns an empty digest. Compression trades memory for accuracy: the number of
+// centroids kept grows linearly with it; 100 is a good default.
+func NewTDigest(compression float64) *TDigest {
+	return &TDigest{Compression: compression, Min: math.Inf(1), Max: math.Inf(-1)}
+}
+
+// Add records one value
+func (t *TDigest) Add(x float64) {
+	t.AddWeighted(x, 1)
+}
+
+// AddWeighted records a value that stands for weight observations
+func (t *TDigest) AddWeighted(x, weight float64) {
+	if math.IsNaN(x) || weight <= 0 {
+		return
+	}
+	t.buffer = append(t.buffer, Centroid{Mean: x, Weight: weight})
+	t.Count += weight
+	t.Min = math.Min(t.Min, x)
+	t.Max = math.Max(t.Max, x)
+	if len(t.buffer) >= int(5*t.Compression) {
+		t.compress()
+	}
+}
+
+// Merge folds another digest into this one, e.g. sketches built over separate shards
+func (t *TDigest) Merge(other *TDigest) {
+	other.compress()
+	if other.Count == 0 {
+		return
+	}
+	t.buffer = append(t.buffer, other.Centroids...)
+	t.Count += other.Count
+	t.Min = math.Min(t.Min, other.Min)
+	t.Max = math.Max(t.Max, other.Max)
+	t.compress()
+}
+
+// compress merges buffered values into the centroids. Neighbouring centroids are combined
+// while their total weight stays under 4·N·q(1-q)/compression, which keeps centroids at
+// the extremes (q near 0 or 1) small.
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.Centroids, t.buffer...)
+	t.buffer = nil
+	sort.Slice(all, func(a, b int) bool { return all[a].Mean < all[b].Mean })
+
+	merged := []Centroid{all[0]}
+	soFar := 0.0
+	for _, c := range all[1:] {
+		cur := &merged[len(merged)-1]
+		proposed := cur.Weight + c.Weight
+		q0 := soFar / t.Count
+		q2 := (soFar + proposed) / t.Count
+		limit := 4 * t.Count * math.Min(q0*(1-q0), q2*(1-q2)) / t.Compression
+		if proposed <= limit {
+			cur.Mean += (c.Mean - cur.Mean) * c.Weight / proposed
+			cur.Weight = proposed
+			continue
+		}
+		soFar += cur.Weight
+		merged = append(merged, c)
+	}
+	t.Centroids = merged
+}
+
+// Quantile returns the estimated value below which a fraction q of the stream falls,
+// interpolating between centroid means; NaN if nothing was added
+func (t *TDigest) Quantile(q float64) float64 {
+	t.compress()
+	if t.Count == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return t.Min
+	}
+	if q >= 1 {
+		return t.Max
+	}
+
+	target := q * t.Count
+	// Each centroid's mean sits at the middle of its weight
+	prevMean, prevPos := t.Min, 0.0
+	cum := 0.0
+	for _, c := range t.Centroids {
+		pos := cum + c.Weight/2
+		if target < pos {
+			return interpolate(prevPos, prevMean, pos, c.Mean, target)
+		}
+		prevMean, prevPos = c.Mean, pos
+		cum += c.Weight
+	}
+	return interpolate(prevPos, prevMean, t.Count, t.Max, target)
+}
+
+// CDF returns the estimated fraction of the stream at or below x
+func (t *TDigest) CDF(x float64) float64 {
+	t.compress()
+	if t.Count == 0 {
+		return math.NaN()
+	}
+	if x < t.Min {
+		return 0
+	}
+	if x >= t.Max {
+		return 1
+	}
+
+	prevMean, prevPos := t.Min, 0.0
+	cum := 0.0
+	for _, c := range t.Centroids {
+		pos := cum + c.Weight/2
+		if x < c.Mean {
+			return interpolate(prevMean, prevPos, c.Mean, pos, x) / t.Count
+		}
+		prevMean, prevPos = c.Mean, pos
+		cum += c.Weight
+	}
+	return interpolate(prevMean, prevPos, t.Max, t.Count, x) / t.Count
+}
+
+// SplitCandidates proposes n thresholds at evenly spaced quantiles, the way a streaming tree
+// picks numeric split points without holding every value
+func (t *TDigest) SplitCandidates(n int) []float64 {
+	var candidates []float64
+	for i := 1; i <= n; i++ {
+		v := t.Quantile(float64(i) / float64(n+1))
+		if len(candidates) == 0 || v > candidates[len(candidates)-1] {
+			candidates = append(candidates, v)
+		}
+	}
+	return candidates
+}
+
+// interpolate returns the y on the line through (x0, y0) and (x1, y1) at x
+func interpolate(x0, y0, x1, y1, x float64) float64 {
+	if x1 == x0 {
+		return y1
+	}
+	return y0 + (y1-y0)*(x-x0)/(x1-x0)
+}
@@ -0,0 +1,190 @@
+// Package split partitions datasets for evaluation. Every function works on row
+// indices, so it applies to any row representation: callers index their own
+// rows with the returned Train and Test slices.
+//
+// The k-fold functions return an iterator, so a custom evaluation loop reads as
+//
+//	folds, err := split.StratifiedKFold(labels, 5, 1)
+//	if err != nil { ... }
+//	for fold := range folds {
+//		train, test := fold.Train, fold.Test
+//		...
+//	}
+package split
+
+import (
+	"fmt"
+	"iter"
+	"math/rand"
+	"sort"
+)
+
+// Fold is one train/test partition of the row indices
+type Fold struct {
+	Index int
+	Train []int
+	Test  []int
+}
+
+// SplitTrainTest shuffles the indices 0..n-1 and holds out testFraction of them as the test set
+func SplitTrainTest(n int, testFraction float64, seed int64) ([]int, []int, error) {
+	if testFraction <= 0 || testFraction >= 1 {
+		return nil, nil, fmt.Errorf("test fraction must be between 0 and 1, got %v", testFraction)
+	}
+	order := rand.New(rand.NewSource(seed)).Perm(n)
+	numTest := int(float64(n)*testFraction + 0.5)
+	test := append([]int{}, order[:numTest]...)
+	train := append([]int{}, order[numTest:]...)
+	sort.Ints(train)
+	sort.Ints(test)
+	return train, test, nil
+}
+
+// StratifiedSplitTrainTest is SplitTrainTest that keeps each label's share of rows
+// the same in the train and test sets
+func StratifiedSplitTrainTest(labels []string, testFraction float64, seed int64) ([]int, []int, error) {
+	if testFraction <= 0 || testFraction >= 1 {
+		return nil, nil, fmt.Errorf("test fraction must be between 0 and 1, got %v", testFraction)
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	var train, test []int
+	byLabel := groupByLabel(labels)
+	for _, label := range sortedKeys(byLabel) {
+		members := byLabel[label]
+		rng.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+		numTest := int(float64(len(members))*testFraction + 0.5)
+		test = append(test, members[:numTest]...)
+		train = append(train, members[numTest:]...)
+	}
+	sort.Ints(train)
+	sort.Ints(test)
+	return train, test, nil
+}
+
+// KFold partitions 0..n-1 into k folds of near-equal size. With shuffle, rows are assigned
+// in a seeded random order; otherwise each fold is a contiguous block.
+func KFold(n, k int, shuffle bool, seed int64) (iter.Seq[Fold], error) {
+	if err := checkFolds(n, k); err != nil {
+		return nil, err
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	if shuffle {
+		order = rand.New(rand.NewSource(seed)).Perm(n)
+	}
+
+	assignment := make([]int, n)
+	for pos, idx := range order {
+		assignment[idx] = pos * k / n
+	}
+	return foldsFromAssignment(assignment, k), nil
+}
+
+// StratifiedKFold partitions rows into k folds so that each fold has roughly the same
+// label proportions as the whole dataset. Rows of each label are shuffled and dealt
+// round-robin across the folds.
+func StratifiedKFold(labels []string, k int, seed int64) (iter.Seq[Fold], error) {
+	if err := checkFolds(len(labels), k); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	assignment := make([]int, len(labels))
+	next := 0
+	byLabel := groupByLabel(labels)
+	for _, label := range sortedKeys(byLabel) {
+		members := byLabel[label]
+		rng.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+		for _, idx := range members {
+			assignment[idx] = next % k
+			next++
+		}
+	}
+	return foldsFromAssignment(assignment, k), nil
+}
+
+// GroupKFold keeps all rows sharing a group value in the same fold, so related rows
+// (for example several events from one user) never leak between train and test.
+// Groups are placed largest first into the currently smallest fold.
+func GroupKFold(groups []string, k int) (iter.Seq[Fold], error) {
+	byGroup := groupByLabel(groups)
+	if len(byGroup) < k {
+		return nil, fmt.Errorf("need at least %d distinct groups for %d folds, got %d", k, k, len(byGroup))
+	}
+	if err := checkFolds(len(groups), k); err != nil {
+		return nil, err
+	}
+
+	names := sortedKeys(byGroup)
+	sort.SliceStable(names, func(i, j int) bool {
+		return len(byGroup[names[i]]) > len(byGroup[names[j]])
+	})
+
+	assignment := make([]int, len(groups))
+	sizes := make([]int, k)
+	for _, name := range names {
+		smallest := 0
+		for f := range sizes {
+			if sizes[f] < sizes[smallest] {
+				smallest = f
+			}
+		}
+		for _, idx := range byGroup[name] {
+			assignment[idx] = smallest
+		}
+		sizes[smallest] += len(byGroup[name])
+	}
+	return foldsFromAssignment(assignment, k), nil
+}
+
+func checkFolds(n, k int) error {
+	if k < 2 {
+		return fmt.Errorf("need at least 2 folds, got %d", k)
+	}
+	if k > n {
+		return fmt.Errorf("cannot make %d folds from %d rows", k, n)
+	}
+	return nil
+}
+
+// groupByLabel returns the row indices of each distinct label, in ascending order
+func groupByLabel(labels []string) map[string][]int {
+	groups := make(map[string][]int)
+	for i, label := range labels {
+		groups[label] = append(groups[label], i)
+	}
+	return groups
+}
+
+// sortedKeys returns the map's keys in order, so seeded shuffles consume randomness identically on every run
+func sortedKeys(groups map[string][]int) []string {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// foldsFromAssignment yields one Fold per fold number, built from each row's fold assignment
+func foldsFromAssignment(assignment []int, k int) iter.Seq[Fold] {
+	return func(yield func(Fold) bool) {
+		for f := 0; f < k; f++ {
+			fold := Fold{Index: f}
+			for idx, a := range assignment {
+				if a == f {
+					fold.Test = append(fold.Test, idx)
+				} else {
+					fold.Train = append(fold.Train, idx)
+				}
+			}
+			if !yield(fold) {
+				return
+			}
+		}
+	}
+}
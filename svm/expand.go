@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"machineLearning/tabular"
+)
+
+// PolynomialExpansion appends products of standardised numeric features to the encoded input,
+// letting the linear SVM fit curved decision boundaries. Each term lists the indices (into the
+// model's Features) of the factors to multiply; a repeated index is a power.
+type PolynomialExpansion struct {
+	Degree          int      `json:"degree"`
+	Columns         []string `json:"columns,omitempty"`
+	InteractionOnly bool     `json:"interactionOnly,omitempty"`
+	Terms           [][]int  `json:"terms"`
+}
+
+// NewPolynomialExpansion generates every term of degree 2..degree over the chosen numeric
+// columns (all numeric columns when columns is empty). With interactionOnly, a column never
+// appears twice in the same term, so only cross products such as a*b are produced.
+func NewPolynomialExpansion(features []tabular.Feature, degree int, columns []string, interactionOnly bool) (*PolynomialExpansion, error) {
+	if degree < 2 {
+		return nil, fmt.Errorf("polynomial degree must be at least 2, got %d", degree)
+	}
+
+	var selected []int
+	if len(columns) == 0 {
+		for f, feature := range features {
+			if feature.Type == "numeric" {
+				selected = append(selected, f)
+			}
+		}
+	} else {
+		for _, name := range columns {
+			found := false
+			for f, feature := range features {
+				if feature.Name != name {
+					continue
+				}
+				if feature.Type != "numeric" {
+					return nil, fmt.Errorf("column %q is not numeric and cannot be expanded", name)
+				}
+				selected = append(selected, f)
+				found = true
+			}
+			if !found {
+				return nil, fmt.Errorf("column %q not found among the features", name)
+			}
+		}
+	}
+
+	expansion := &PolynomialExpansion{Degree: degree, Columns: columns, InteractionOnly: interactionOnly}
+
+	// Walk combinations in non-decreasing index order so each term is generated once
+	var build func(start int, term []int)
+	build = func(start int, term []int) {
+		if len(term) >= 2 {
+			expansion.Terms = append(expansion.Terms, append([]int{}, term...))
+		}
+		if len(term) == degree {
+			return
+		}
+		for s := start; s < len(selected); s++ {
+			next := s
+			if interactionOnly {
+				next = s + 1
+			}
+			build(next, append(term, selected[s]))
+		}
+	}
+	build(0, nil)
+
+	return expansion, nil
+}
+
+// Expand returns x with one extra value per term appended
+func (p *PolynomialExpansion) Expand(features []tabular.Feature, x []float64) []float64 {
+	offsets := make([]int, len(features))
+	pos := 0
+	for f, feature := range features {
+		offsets[f] = pos
+		switch feature.Type {
+		case "numeric":
+			pos++
+		case "datetime":
+			pos += 2 * len(feature.Cycles)
+		default:
+			pos += len(feature.Categories)
+		}
+	}
+
+	expanded := append([]float64{}, x...)
+	for _, term := range p.Terms {
+		product := 1.0
+		for _, f := range term {
+			product *= x[offsets[f]]
+		}
+		expanded = append(expanded, product)
+	}
+	return expanded
+}
+
+// TermNames returns a readable name such as "a*b" or "a*a" for every generated term
+func (p *PolynomialExpansion) TermNames(features []tabular.Feature) []string {
+	names := make([]string, len(p.Terms))
+	for t, term := range p.Terms {
+		factors := make([]string, len(term))
+		for i, f := range term {
+			factors[i] = features[f].Name
+		}
+		names[t] = strings.Join(factors, "*")
+	}
+	return names
+}
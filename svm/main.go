@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"machineLearning/history"
+	"machineLearning/tabular"
+)
+
+// Model is the envelope saved to disk: the feature encoding, the class labels and the SVM weights
+type Model struct {
+	Type      string               `json:"type"`
+	Target    string               `json:"target"`
+	Classes   []string             `json:"classes"`
+	Features  []tabular.Feature    `json:"features"`
+	Expansion *PolynomialExpansion `json:"expansion,omitempty"`
+	SVM       *LinearSVM           `json:"svm"`
+	History   []history.Epoch      `json:"history,omitempty"`
+}
+
+// Encode turns a CSV row into the SVM input, applying the polynomial expansion if the model has one
+func (m *Model) Encode(colIndex []int, row []string) []float64 {
+	x := tabular.EncodeRow(m.Features, colIndex, row)
+	if m.Expansion != nil {
+		x = m.Expansion.Expand(m.Features, x)
+	}
+	return x
+}
+
+// PolynomialOptions selects the optional polynomial feature expansion; Degree below 2 disables it
+type PolynomialOptions struct {
+	Degree          int
+	Columns         []string
+	InteractionOnly bool
+}
+
+// Train the SVM and save the model envelope as JSON
+func TrainModel(inputFile, targetCol, outputFile string, cfg SVMConfig, poly PolynomialOptions) error {
+	header, rows, err := tabular.LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+
+	targetIndex := tabular.ColumnIndex(header, targetCol)
+	if targetIndex == -1 {
+		return fmt.Errorf("target column %q not found in %s", targetCol, inputFile)
+	}
+
+	model := &Model{Type: "svm", Target: targetCol, Features: tabular.BuildFeatures(header, rows, targetIndex)}
+	colIndex, err := tabular.FeatureColumns(model.Features, header)
+	if err != nil {
+		return err
+	}
+
+	if poly.Degree >= 2 {
+		model.Expansion, err = NewPolynomialExpansion(model.Features, poly.Degree, poly.Columns, poly.InteractionOnly)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Expanded features:", model.Expansion.TermNames(model.Features))
+	}
+
+	classIndex := make(map[string]int)
+	X := make([][]float64, len(rows))
+	Y := make([]int, len(rows))
+	for i, row := range rows {
+		label := row[targetIndex]
+		if _, ok := classIndex[label]; !ok {
+			classIndex[label] = len(model.Classes)
+			model.Classes = append(model.Classes, label)
+		}
+		X[i] = model.Encode(colIndex, row)
+		Y[i] = classIndex[label]
+	}
+
+	svm, epochs, err := TrainSVM(X, Y, len(model.Classes), cfg)
+	if err != nil {
+		return err
+	}
+	model.SVM = svm
+	model.History = epochs
+	fmt.Printf("Training hinge loss: %.4f, accuracy: %.4f\n", svm.HingeLoss(X, Y), svm.Accuracy(X, Y))
+
+	modelFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating model file: %v", err)
+	}
+	defer modelFile.Close()
+
+	encoder := json.NewEncoder(modelFile)
+	if err := encoder.Encode(model); err != nil {
+		return fmt.Errorf("error writing model: %v", err)
+	}
+
+	fmt.Println("Model saved to", outputFile)
+	return nil
+}
+
+// LoadModel reads a model envelope from a JSON file
+func LoadModel(modelFile string) (*Model, error) {
+	file, err := os.Open(modelFile)
+	if err != nil {
+		return nil, fmt.Errorf("error opening model file: %v", err)
+	}
+	defer file.Close()
+
+	var model Model
+	if err := json.NewDecoder(file).Decode(&model); err != nil {
+		return nil, fmt.Errorf("error decoding model file: %v", err)
+	}
+	if model.SVM == nil {
+		return nil, fmt.Errorf("model file %s has no SVM weights", modelFile)
+	}
+	return &model, nil
+}
+
+// InspectModel prints a summary of a saved model, or with showHistory set, dumps its
+// per-epoch training curve as CSV to outputFile (standard output when empty) for plotting
+func InspectModel(modelFile string, showHistory bool, outputFile string) error {
+	model, err := LoadModel(modelFile)
+	if err != nil {
+		return err
+	}
+
+	if showHistory {
+		if len(model.History) == 0 {
+			return fmt.Errorf("model %s has no training history", modelFile)
+		}
+		return history.Save(model.History, outputFile)
+	}
+
+	fmt.Println("Type:", model.Type)
+	fmt.Println("Target:", model.Target)
+	fmt.Println("Classes:", model.Classes)
+	fmt.Println("Features:", len(model.Features))
+	for _, f := range model.Features {
+		fmt.Printf("  %s (%s)\n", f.Name, f.Type)
+	}
+	history.Summarize(model.History)
+	return nil
+}
+
+// PredictFromModel scores a CSV file, appending the predicted class
+func PredictFromModel(inputFile, modelFile, outputFile string) error {
+	header, rows, err := tabular.LoadCsv(inputFile)
+	if err != nil {
+		return err
+	}
+
+	model, err := LoadModel(modelFile)
+	if err != nil {
+		return err
+	}
+
+	colIndex, err := tabular.FeatureColumns(model.Features, header)
+	if err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer outFile.Close()
+
+	writer := csv.NewWriter(outFile)
+	defer writer.Flush()
+
+	writer.Write(append(append([]string{}, header...), "Prediction"))
+	for _, row := range rows {
+		class := model.SVM.Predict(model.Encode(colIndex, row))
+		writer.Write(append(append([]string{}, row...), model.Classes[class]))
+	}
+
+	fmt.Println("Predictions saved to", outputFile)
+	return nil
+}
+
+func main() {
+	// Define CLI flags
+	command := flag.String("c", "", "Command: train, predict or inspect")
+	inputFile := flag.String("i", "", "Input CSV file")
+	targetCol := flag.String("t", "", "Target column (only for training)")
+	modelFile := flag.String("m", "", "Model file (only for prediction)")
+	outputFile := flag.String("o", "", "Output file")
+	showHistory := flag.Bool("history", false, "Dump the per-epoch training history as CSV (inspect)")
+	lambda := flag.Float64("lambda", 0.01, "L2 regularization strength")
+	epochs := flag.Int("epochs", 50, "Passes over the training data")
+	valSplit := flag.Float64("val-split", 0, "Fraction of rows held out for early stopping (0 = train on all rows for every epoch)")
+	patience := flag.Int("patience", 5, "Stop after this many epochs without validation improvement")
+	seed := flag.Int64("seed", 1, "Random seed")
+	polyDegree := flag.Int("poly-degree", 1, "Add polynomial terms up to this degree (2 or more enables expansion)")
+	polyColumns := flag.String("poly-columns", "", "Comma-separated numeric columns to expand (default: all numeric)")
+	interactionOnly := flag.Bool("interaction-only", false, "Only add products of distinct columns, no powers")
+
+	// Parse flags
+	flag.Parse()
+
+	// Execute command
+	switch *command {
+	case "train":
+		if *inputFile == "" || *targetCol == "" || *outputFile == "" {
+			fmt.Println("Usage: svm -c train -i <input.csv> -t <target> -o <model.json> [-lambda 0.01] [-epochs 50] [-val-split 0.2 -patience 5] [-poly-degree 2]")
+			return
+		}
+		cfg := SVMConfig{Lambda: *lambda, Epochs: *epochs, ValidationSplit: *valSplit, Patience: *patience, Seed: *seed}
+		poly := PolynomialOptions{Degree: *polyDegree, InteractionOnly: *interactionOnly}
+		if *polyColumns != "" {
+			poly.Columns = strings.Split(*polyColumns, ",")
+		}
+		err := TrainModel(*inputFile, *targetCol, *outputFile, cfg, poly)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	case "predict":
+		if *inputFile == "" || *modelFile == "" || *outputFile == "" {
+			fmt.Println("Usage: svm -c predict -i <test.csv> -m <model.json> -o <predictions.csv>")
+			return
+		}
+		err := PredictFromModel(*inputFile, *modelFile, *outputFile)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	case "inspect":
+		if *modelFile == "" {
+			fmt.Println("Usage: svm -c inspect -m <model.json> [-history] [-o <history.csv>]")
+			return
+		}
+		err := InspectModel(*modelFile, *showHistory, *outputFile)
+		if err != nil {
+			fmt.Println("Error:", err)
+		}
+
+	default:
+		fmt.Println("Invalid command. Use 'train', 'predict' or 'inspect'.")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"machineLearning/history"
+	"machineLearning/loss"
+	"machineLearning/mat"
+)
+
+// LinearSVM is a one-vs-rest linear SVM: one weight vector and bias per class
+type LinearSVM struct {
+	Weights [][]float64 `json:"weights"`
+	Biases  []float64   `json:"biases"`
+	Lambda  float64     `json:"lambda"`
+}
+
+// SVMConfig holds the Pegasos training parameters. When ValidationSplit is set, that
+// fraction of rows is held out and training stops once the validation hinge loss has not
+// improved for Patience epochs, keeping the weights of the best epoch.
+type SVMConfig struct {
+	Lambda          float64
+	Epochs          int
+	ValidationSplit float64
+	Patience        int
+	Seed            int64
+}
+
+// TrainSVM fits one binary hinge-loss classifier per class with the Pegasos
+// stochastic sub-gradient method. Each class is trained against all others.
+// The losses and accuracies of every epoch are returned alongside the model.
+func TrainSVM(X [][]float64, Y []int, numClasses int, cfg SVMConfig) (*LinearSVM, []history.Epoch, error) {
+	if len(X) == 0 {
+		return nil, nil, fmt.Errorf("no training samples")
+	}
+	if cfg.Lambda <= 0 {
+		return nil, nil, fmt.Errorf("lambda must be positive, got %v", cfg.Lambda)
+	}
+
+	// Hold out a shuffled validation split for early stopping
+	trainX, trainY := X, Y
+	var valX [][]float64
+	var valY []int
+	if numVal := int(float64(len(X)) * cfg.ValidationSplit); numVal > 0 {
+		trainX, trainY = nil, nil
+		for i, idx := range rand.New(rand.NewSource(cfg.Seed)).Perm(len(X)) {
+			if i < numVal {
+				valX, valY = append(valX, X[idx]), append(valY, Y[idx])
+			} else {
+				trainX, trainY = append(trainX, X[idx]), append(trainY, Y[idx])
+			}
+		}
+	}
+
+	dim := len(X[0])
+	svm := &LinearSVM{
+		Weights: make([][]float64, numClasses),
+		Biases:  make([]float64, numClasses),
+		Lambda:  cfg.Lambda,
+	}
+	rngs := make([]*rand.Rand, numClasses)
+	steps := make([]int, numClasses)
+	for c := 0; c < numClasses; c++ {
+		rngs[c] = rand.New(rand.NewSource(cfg.Seed + int64(c)))
+		svm.Weights[c] = make([]float64, dim)
+	}
+
+	best := svm.clone()
+	bestLoss := math.Inf(1)
+	sinceBest := 0
+	var epochs []history.Epoch
+
+	for epoch := 1; epoch <= cfg.Epochs; epoch++ {
+		for c := 0; c < numClasses; c++ {
+			w := svm.Weights[c]
+			for _, i := range rngs[c].Perm(len(trainX)) {
+				steps[c]++
+				eta := 1 / (cfg.Lambda * float64(steps[c]))
+
+				y := -1.0
+				if trainY[i] == c {
+					y = 1.0
+				}
+				grad := loss.HingeGradient(mat.Dot(w, trainX[i])+svm.Biases[c], y)
+
+				// Shrink towards zero for the L2 term, then step on the hinge if the margin is violated
+				for d := range w {
+					w[d] *= 1 - eta*cfg.Lambda
+				}
+				if grad != 0 {
+					for d := range w {
+						w[d] -= eta * grad * trainX[i][d]
+					}
+					svm.Biases[c] -= eta * grad
+				}
+			}
+		}
+
+		stats := history.Epoch{Epoch: epoch, TrainLoss: svm.HingeLoss(trainX, trainY), TrainAccuracy: svm.Accuracy(trainX, trainY)}
+		if len(valX) > 0 {
+			stats.ValidationLoss, stats.ValidationAccuracy = svm.HingeLoss(valX, valY), svm.Accuracy(valX, valY)
+			stats.HasValidation = true
+		}
+		epochs = append(epochs, stats)
+
+		if !stats.HasValidation {
+			continue
+		}
+		if stats.ValidationLoss < bestLoss {
+			bestLoss = stats.ValidationLoss
+			best = svm.clone()
+			sinceBest = 0
+		} else if sinceBest++; cfg.Patience > 0 && sinceBest >= cfg.Patience {
+			fmt.Printf("Early stopping at epoch %d, best validation hinge loss %.4f\n", epoch, bestLoss)
+			break
+		}
+	}
+
+	if len(valX) > 0 {
+		return best, epochs, nil
+	}
+	return svm, epochs, nil
+}
+
+// clone returns a deep copy of the model
+func (s *LinearSVM) clone() *LinearSVM {
+	c := &LinearSVM{Weights: make([][]float64, len(s.Weights)), Biases: append([]float64{}, s.Biases...), Lambda: s.Lambda}
+	for i, w := range s.Weights {
+		c.Weights[i] = append([]float64{}, w...)
+	}
+	return c
+}
+
+// Scores returns the signed distance-like score of x for every class
+func (s *LinearSVM) Scores(x []float64) []float64 {
+	scores := make([]float64, len(s.Weights))
+	for c, w := range s.Weights {
+		scores[c] = mat.Dot(w, x) + s.Biases[c]
+	}
+	return scores
+}
+
+// Predict returns the index of the class with the highest score
+func (s *LinearSVM) Predict(x []float64) int {
+	return argmax(s.Scores(x))
+}
+
+// HingeLoss returns the mean one-vs-rest hinge loss summed over the classes
+func (s *LinearSVM) HingeLoss(X [][]float64, Y []int) float64 {
+	if len(X) == 0 {
+		return 0
+	}
+	total := 0.0
+	for i, x := range X {
+		for c, score := range s.Scores(x) {
+			y := -1.0
+			if Y[i] == c {
+				y = 1.0
+			}
+			total += loss.Hinge(score, y)
+		}
+	}
+	return total / float64(len(X))
+}
+
+// Accuracy returns the fraction of samples whose predicted class matches the label
+func (s *LinearSVM) Accuracy(X [][]float64, Y []int) float64 {
+	if len(X) == 0 {
+		return 0
+	}
+	correct := 0
+	for i, x := range X {
+		if s.Predict(x) == Y[i] {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(X))
+}
+
+// argmax returns the index of the largest value
+func argmax(values []float64) int {
+	best := 0
+	for i, v := range values {
+		if v > values[best] {
+			best = i
+		}
+	}
+	return best
+}
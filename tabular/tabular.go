@@ -0,0 +1,163 @@
+// Package tabular reads CSV files as raw string rows and encodes them as the numeric input
+// vectors the gradient-trained models (mlp, svm) learn from.
+package tabular
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"machineLearning/datetime"
+)
+
+// Feature describes how one input column is encoded for a model. Numeric columns are
+// standardised with the training mean and standard deviation; datetime columns become sin/cos
+// pairs for each cyclical component (month, day of week, hour); categorical columns are
+// one-hot encoded over the categories seen during training.
+type Feature struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Mean       float64  `json:"mean,omitempty"`
+	Std        float64  `json:"std,omitempty"`
+	Layout     string   `json:"layout,omitempty"`
+	Cycles     []string `json:"cycles,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// LoadCsv loads a CSV file as a header and raw string records
+func LoadCsv(filename string) ([]string, [][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading file: %v", err)
+	}
+
+	if len(records) < 2 {
+		return nil, nil, fmt.Errorf("insufficient data in CSV file")
+	}
+
+	return records[0], records[1:], nil
+}
+
+// ColumnIndex returns the position of a column in the header, or -1 if it is absent
+func ColumnIndex(header []string, name string) int {
+	for i, col := range header {
+		if col == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// BuildFeatures derives the encoding for every non-target column from the training data
+func BuildFeatures(header []string, rows [][]string, targetIndex int) []Feature {
+	var features []Feature
+	for col, name := range header {
+		if col == targetIndex {
+			continue
+		}
+
+		values := make([]float64, 0, len(rows))
+		isNumeric := true
+		for _, row := range rows {
+			v, err := strconv.ParseFloat(strings.TrimSpace(row[col]), 64)
+			if err != nil {
+				isNumeric = false
+				break
+			}
+			values = append(values, v)
+		}
+
+		if isNumeric {
+			mean, std := meanStd(values)
+			features = append(features, Feature{Name: name, Type: "numeric", Mean: mean, Std: std})
+			continue
+		}
+
+		column := make([]string, len(rows))
+		for i, row := range rows {
+			column[i] = row[col]
+		}
+		if layout := datetime.DetectLayout(column); layout != "" {
+			features = append(features, Feature{Name: name, Type: "datetime", Layout: layout, Cycles: datetime.Cycles(layout, column)})
+			continue
+		}
+
+		seen := make(map[string]bool)
+		feature := Feature{Name: name, Type: "categorical"}
+		for _, row := range rows {
+			if !seen[row[col]] {
+				seen[row[col]] = true
+				feature.Categories = append(feature.Categories, row[col])
+			}
+		}
+		features = append(features, feature)
+	}
+	return features
+}
+
+// meanStd returns the mean and population standard deviation, using 1 for constant columns
+func meanStd(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	std := math.Sqrt(variance / float64(len(values)))
+	if std == 0 {
+		std = 1
+	}
+	return mean, std
+}
+
+// EncodeRow turns one CSV row into a model's input vector. colIndex maps each
+// feature to its column in the row's header.
+func EncodeRow(features []Feature, colIndex []int, row []string) []float64 {
+	var x []float64
+	for f, feature := range features {
+		value := strings.TrimSpace(row[colIndex[f]])
+		if feature.Type == "numeric" {
+			v, _ := strconv.ParseFloat(value, 64)
+			x = append(x, (v-feature.Mean)/feature.Std)
+			continue
+		}
+		if feature.Type == "datetime" {
+			x = datetime.EncodeCyclical(x, feature.Cycles, feature.Layout, value)
+			continue
+		}
+		for _, category := range feature.Categories {
+			if value == category {
+				x = append(x, 1)
+			} else {
+				x = append(x, 0)
+			}
+		}
+	}
+	return x
+}
+
+// FeatureColumns finds every model feature in a header
+func FeatureColumns(features []Feature, header []string) ([]int, error) {
+	colIndex := make([]int, len(features))
+	for f, feature := range features {
+		colIndex[f] = ColumnIndex(header, feature.Name)
+		if colIndex[f] == -1 {
+			return nil, fmt.Errorf("column %q required by the model is missing", feature.Name)
+		}
+	}
+	return colIndex, nil
+}
@@ -0,0 +1,121 @@
+package trees
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// height returns the cached height of a subtree, 0 for an empty one
+func height[K cmp.Ordered, V any](n *TreeNode[K, V]) int {
+	if n == nil {
+		return 0
+	}
+	return n.Height
+}
+
+// updateHeight recomputes a node's height from its children
+func updateHeight[K cmp.Ordered, V any](n *TreeNode[K, V]) {
+	n.Height = max(height(n.Left), height(n.Right)) + 1
+}
+
+// BTreeBalanceFactor returns the height of the left subtree minus that of the right one.
+// AVL balancing keeps it between -1 and 1 at every node.
+func BTreeBalanceFactor[K cmp.Ordered, V any](n *TreeNode[K, V]) int {
+	if n == nil {
+		return 0
+	}
+	return height(n.Left) - height(n.Right)
+}
+
+// rotateRight lifts n's left child into n's place and returns it
+func rotateRight[K cmp.Ordered, V any](n *TreeNode[K, V]) *TreeNode[K, V] {
+	l := n.Left
+	n.Left = l.Right
+	if l.Right != nil {
+		l.Right.Parent = n
+	}
+	l.Right = n
+	l.Parent = n.Parent
+	n.Parent = l
+	updateHeight(n)
+	updateHeight(l)
+	return l
+}
+
+// rotateLeft lifts n's right child into n's place and returns it
+func rotateLeft[K cmp.Ordered, V any](n *TreeNode[K, V]) *TreeNode[K, V] {
+	r := n.Right
+	n.Right = r.Left
+	if r.Left != nil {
+		r.Left.Parent = n
+	}
+	r.Left = n
+	r.Parent = n.Parent
+	n.Parent = r
+	updateHeight(n)
+	updateHeight(r)
+	return r
+}
+
+// rebalance restores the AVL property at n after one of its subtrees changed height by one,
+// returning the node that now roots the subtree
+func rebalance[K cmp.Ordered, V any](n *TreeNode[K, V]) *TreeNode[K, V] {
+	updateHeight(n)
+	switch bf := BTreeBalanceFactor(n); {
+	case bf > 1:
+		if BTreeBalanceFactor(n.Left) < 0 {
+			n.Left = rotateLeft(n.Left)
+		}
+		return rotateRight(n)
+	case bf < -1:
+		if BTreeBalanceFactor(n.Right) > 0 {
+			n.Right = rotateRight(n.Right)
+		}
+		return rotateLeft(n)
+	}
+	return n
+}
+
+// BTreeCheck verifies the tree's invariants: keys in search order, consistent Parent
+// pointers, correct cached heights and AVL balance. It returns the first violation found.
+func BTreeCheck[K cmp.Ordered, V any](root *TreeNode[K, V]) error {
+	if root != nil && root.Parent != nil {
+		return fmt.Errorf("root %v has a parent", root.Key)
+	}
+	_, err := checkSubtree(root, nil, nil)
+	return err
+}
+
+// checkSubtree checks the subtree at n, whose keys must lie strictly between lo and hi when set,
+// and returns its height
+func checkSubtree[K cmp.Ordered, V any](n *TreeNode[K, V], lo, hi *K) (int, error) {
+	if n == nil {
+		return 0, nil
+	}
+	if (lo != nil && n.Key <= *lo) || (hi != nil && n.Key >= *hi) {
+		return 0, fmt.Errorf("key %v is out of search order", n.Key)
+	}
+	for _, child := range []*TreeNode[K, V]{n.Left, n.Right} {
+		if child != nil && child.Parent != n {
+			return 0, fmt.Errorf("child %v of %v has the wrong parent", child.Key, n.Key)
+		}
+	}
+
+	lh, err := checkSubtree(n.Left, lo, &n.Key)
+	if err != nil {
+		return 0, err
+	}
+	rh, err := checkSubtree(n.Right, &n.Key, hi)
+	if err != nil {
+		return 0, err
+	}
+
+	h := max(lh, rh) + 1
+	if n.Height != h {
+		return 0, fmt.Errorf("node %v caches height %d, actual %d", n.Key, n.Height, h)
+	}
+	if lh-rh > 1 || rh-lh > 1 {
+		return 0, fmt.Errorf("node %v is unbalanced (balance factor %d)", n.Key, lh-rh)
+	}
+	return h, nil
+}
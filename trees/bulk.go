@@ -0,0 +1,39 @@
+package trees
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// BTreeFromSorted builds a perfectly balanced tree from keys in ascending order in O(n).
+// Repeated keys become a single node whose Count records how often the key appeared, as
+// with DuplicateCount. Values are left at their zero value.
+func BTreeFromSorted[K cmp.Ordered, V any](keys []K) (*TreeNode[K, V], error) {
+	var unique []K
+	var counts []int
+	for i, key := range keys {
+		if i > 0 && key < keys[i-1] {
+			return nil, fmt.Errorf("keys are not sorted: %v follows %v at index %d", key, keys[i-1], i)
+		}
+		if i > 0 && key == keys[i-1] {
+			counts[len(counts)-1]++
+			continue
+		}
+		unique = append(unique, key)
+		counts = append(counts, 1)
+	}
+	return buildBalanced[K, V](unique, counts, nil), nil
+}
+
+// buildBalanced roots the subtree at the middle key so both halves differ in size by at most one
+func buildBalanced[K cmp.Ordered, V any](keys []K, counts []int, parent *TreeNode[K, V]) *TreeNode[K, V] {
+	if len(keys) == 0 {
+		return nil
+	}
+	mid := len(keys) / 2
+	n := &TreeNode[K, V]{Key: keys[mid], Count: counts[mid], Parent: parent}
+	n.Left = buildBalanced(keys[:mid], counts[:mid], n)
+	n.Right = buildBalanced(keys[mid+1:], counts[mid+1:], n)
+	updateHeight(n)
+	return n
+}
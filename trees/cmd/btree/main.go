@@ -2,15 +2,28 @@ package main
 
 import (
 	"fmt"
+
+	"machineLearning/trees"
 )
 
 func main() {
-	root := &TreeNode{Data: "4"}
-	BTreeInsertData(root, "1")
-	BTreeInsertData(root, "7")
-	BTreeInsertData(root, "5")
-	height:=BTreeLevelCount(root)
-	fmt.Println(height)
+	var root *trees.TreeNode[float64, string]
+	root = trees.BTreeInsertData(root, 4, "four")
+	root = trees.BTreeInsertData(root, 1, "one")
+	root = trees.BTreeInsertData(root, 7, "seven")
+	root = trees.BTreeInsertData(root, 5.5, "five and a half")
+	for i := 10.0; i < 100; i++ {
+		root = trees.BTreeInsertData(root, i, "sorted")
+	}
+	for i := 10.0; i < 100; i += 2 {
+		root = trees.BTreeDelete(root, i)
+	}
+	height := trees.BTreeLevelCount(root)
+	fmt.Println(height, trees.BTreeCheck(root))
+	for key, value := range trees.BTreeAscendRange(root, 0, 10) {
+		fmt.Println(key, value)
+	}
+	fmt.Print(trees.BTreeString(root))
 	// selected := BTreeSearchItem(root, "7")
 	// // BTreeApplyInorder(root, fmt.Println)
 	// // BTreeApplyPreorder(root,fmt.Println)
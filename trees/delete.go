@@ -0,0 +1,49 @@
+package trees
+
+import "cmp"
+
+// BTreeDelete removes key, with all its duplicates, from the tree and returns the new root. A leaf is simply dropped,
+// a node with one child is replaced by that child, and a node with two children takes the
+// key and value of its in-order successor, which is then removed from the right subtree.
+// Parent pointers and AVL balance are restored on the way back up.
+func BTreeDelete[K cmp.Ordered, V any](root *TreeNode[K, V], key K) *TreeNode[K, V] {
+	if root == nil {
+		return nil
+	}
+
+	switch {
+	case key < root.Key:
+		root.Left = BTreeDelete(root.Left, key)
+		if root.Left != nil {
+			root.Left.Parent = root
+		}
+	case key > root.Key:
+		root.Right = BTreeDelete(root.Right, key)
+		if root.Right != nil {
+			root.Right.Parent = root
+		}
+	default:
+		if root.Left == nil || root.Right == nil {
+			child := root.Left
+			if child == nil {
+				child = root.Right
+			}
+			if child != nil {
+				child.Parent = root.Parent
+			}
+			return child
+		}
+
+		successor := root.Right
+		for successor.Left != nil {
+			successor = successor.Left
+		}
+		root.Key, root.Value = successor.Key, successor.Value
+		root.Count, root.Duplicates = successor.Count, successor.Duplicates
+		root.Right = BTreeDelete(root.Right, successor.Key)
+		if root.Right != nil {
+			root.Right.Parent = root
+		}
+	}
+	return rebalance(root)
+}
@@ -0,0 +1,75 @@
+package trees
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// DuplicatePolicy decides what inserting a key that is already in the tree does
+type DuplicatePolicy int
+
+const (
+	// DuplicateIgnore leaves the existing entry unchanged
+	DuplicateIgnore DuplicatePolicy = iota
+	// DuplicateReject returns an error
+	DuplicateReject
+	// DuplicateCount increments the node's Count and keeps the first value
+	DuplicateCount
+	// DuplicateKeepAll increments Count and appends the value to the node's Duplicates
+	DuplicateKeepAll
+)
+
+// BTreeInsertWithPolicy inserts key with its value, handling an existing key according to
+// policy, and returns the new root
+func BTreeInsertWithPolicy[K cmp.Ordered, V any](root *TreeNode[K, V], key K, value V, policy DuplicatePolicy) (*TreeNode[K, V], error) {
+	if root == nil {
+		return &TreeNode[K, V]{Key: key, Value: value, Height: 1, Count: 1}, nil
+	}
+
+	var err error
+	switch {
+	case key < root.Key:
+		root.Left, err = BTreeInsertWithPolicy(root.Left, key, value, policy)
+		if root.Left != nil {
+			root.Left.Parent = root
+		}
+	case key > root.Key:
+		root.Right, err = BTreeInsertWithPolicy(root.Right, key, value, policy)
+		if root.Right != nil {
+			root.Right.Parent = root
+		}
+	default:
+		switch policy {
+		case DuplicateReject:
+			return root, fmt.Errorf("duplicate key %v", key)
+		case DuplicateCount:
+			root.Count++
+		case DuplicateKeepAll:
+			root.Count++
+			root.Duplicates = append(root.Duplicates, value)
+		}
+		return root, nil
+	}
+	if err != nil {
+		return root, err
+	}
+	return rebalance(root), nil
+}
+
+// BTreeCount returns how many times key was inserted, 0 if it is absent
+func BTreeCount[K cmp.Ordered, V any](root *TreeNode[K, V], key K) int {
+	if node := BTreeSearchItem(root, key); node != nil {
+		return node.Count
+	}
+	return 0
+}
+
+// BTreeValues returns every value stored under key: the first one followed by the duplicates
+// kept by DuplicateKeepAll
+func BTreeValues[K cmp.Ordered, V any](root *TreeNode[K, V], key K) []V {
+	node := BTreeSearchItem(root, key)
+	if node == nil {
+		return nil
+	}
+	return append([]V{node.Value}, node.Duplicates...)
+}
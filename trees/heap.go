@@ -0,0 +1,99 @@
+package trees
+
+// Heap is a binary heap ordered by less: Pop returns the item for which less holds against
+// every other item. Use a less of a < b for a min-heap and a > b for a max-heap.
+type Heap[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+// NewHeap returns an empty heap ordered by less
+func NewHeap[T any](less func(a, b T) bool) *Heap[T] {
+	return &Heap[T]{less: less}
+}
+
+// Len returns the number of items in the heap
+func (h *Heap[T]) Len() int {
+	return len(h.items)
+}
+
+// Push adds an item in O(log n)
+func (h *Heap[T]) Push(item T) {
+	h.items = append(h.items, item)
+	h.up(len(h.items) - 1)
+}
+
+// Peek returns the top item without removing it, and false if the heap is empty
+func (h *Heap[T]) Peek() (T, bool) {
+	if len(h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return h.items[0], true
+}
+
+// Pop removes and returns the top item in O(log n), and false if the heap is empty
+func (h *Heap[T]) Pop() (T, bool) {
+	if len(h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	top := h.items[0]
+	last := len(h.items) - 1
+	h.items[0] = h.items[last]
+	var zero T
+	h.items[last] = zero // drop the reference so it can be collected
+	h.items = h.items[:last]
+	h.down(0)
+	return top, true
+}
+
+// PushBounded adds an item while keeping at most k items, evicting the top one when full.
+// With a min-heap this keeps the k largest items seen, which is how top-k aggregation works.
+func (h *Heap[T]) PushBounded(item T, k int) {
+	if len(h.items) < k {
+		h.Push(item)
+		return
+	}
+	if k > 0 && h.less(h.items[0], item) {
+		h.items[0] = item
+		h.down(0)
+	}
+}
+
+// Drain pops every item, returning them in heap order
+func (h *Heap[T]) Drain() []T {
+	out := make([]T, 0, len(h.items))
+	for len(h.items) > 0 {
+		item, _ := h.Pop()
+		out = append(out, item)
+	}
+	return out
+}
+
+func (h *Heap[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(h.items[i], h.items[parent]) {
+			return
+		}
+		h.items[i], h.items[parent] = h.items[parent], h.items[i]
+		i = parent
+	}
+}
+
+func (h *Heap[T]) down(i int) {
+	for {
+		smallest := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(h.items) && h.less(h.items[child], h.items[smallest]) {
+				smallest = child
+			}
+		}
+		if smallest == i {
+			return
+		}
+		h.items[i], h.items[smallest] = h.items[smallest], h.items[i]
+		i = smallest
+	}
+}
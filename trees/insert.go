@@ -0,0 +1,81 @@
+// Package trees holds the tree data structures used by the ML code: a balanced binary
+// search tree for ordered indexes and a KD-tree for nearest-neighbour queries.
+package trees
+
+import "cmp"
+
+// TreeNode is a binary search tree node ordered by Key and carrying a Value payload.
+// Keys can be any ordered type: strings, ints, floats, or dates as Unix timestamps.
+type TreeNode[K cmp.Ordered, V any] struct {
+	Left, Right, Parent *TreeNode[K, V]
+	Key                 K
+	Value               V
+	Height              int
+	Count               int // times the key was inserted, see DuplicatePolicy
+	Duplicates          []V // values of repeated inserts under DuplicateKeepAll
+}
+
+// BTreeInsertData inserts key with its value and returns the new root, which may differ from
+// the old one because the tree is rebalanced AVL-style on the way back up. Keys already in
+// the tree are left unchanged; use BTreeInsertWithPolicy to count or reject duplicates.
+func BTreeInsertData[K cmp.Ordered, V any](root *TreeNode[K, V], key K, value V) *TreeNode[K, V] {
+	root, _ = BTreeInsertWithPolicy(root, key, value, DuplicateIgnore) // ignoring never fails
+	return root
+}
+
+// BTreeApplyInorder calls f on every node in ascending key order
+func BTreeApplyInorder[K cmp.Ordered, V any](root *TreeNode[K, V], f func(K, V)) {
+	if root == nil {
+		return
+	}
+	BTreeApplyInorder(root.Left, f)
+	f(root.Key, root.Value)
+	BTreeApplyInorder(root.Right, f)
+}
+
+// BTreeApplyPreorder calls f on every node, visiting each node before its children
+func BTreeApplyPreorder[K cmp.Ordered, V any](root *TreeNode[K, V], f func(K, V)) {
+	if root == nil {
+		return
+	}
+	f(root.Key, root.Value)
+	BTreeApplyPreorder(root.Left, f)
+	BTreeApplyPreorder(root.Right, f)
+}
+
+// BTreeSearchItem returns the node holding key, or nil if it is absent
+func BTreeSearchItem[K cmp.Ordered, V any](root *TreeNode[K, V], key K) *TreeNode[K, V] {
+	if root == nil {
+		return nil
+	}
+
+	if key == root.Key {
+		return root
+	}
+
+	if key < root.Key {
+		return BTreeSearchItem(root.Left, key)
+	}
+	if key > root.Key {
+		return BTreeSearchItem(root.Right, key)
+	}
+	return root
+}
+
+// BTreeLevelCount returns the height of the tree
+func BTreeLevelCount[K cmp.Ordered, V any](root *TreeNode[K, V]) int {
+	if root == nil {
+		return 0
+	}
+	lheight := BTreeLevelCount(root.Left)
+	rheight := BTreeLevelCount(root.Right)
+	return max(lheight, rheight) + 1
+}
+
+func max(a, b int) int {
+	max := a
+	if b > a {
+		max = b
+	}
+	return max
+}
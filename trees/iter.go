@@ -0,0 +1,57 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+)
+
+// BTreeAscend yields every key and value in ascending key order. Nodes are visited lazily,
+// so breaking out of the loop stops the walk.
+func BTreeAscend[K cmp.Ordered, V any](root *TreeNode[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		ascend(root, nil, nil, yield)
+	}
+}
+
+// BTreeDescend yields every key and value in descending key order
+func BTreeDescend[K cmp.Ordered, V any](root *TreeNode[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		descend(root, yield)
+	}
+}
+
+// BTreeAscendRange yields the keys in [lo, hi) in ascending order, skipping subtrees that
+// lie entirely outside the range
+func BTreeAscendRange[K cmp.Ordered, V any](root *TreeNode[K, V], lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		ascend(root, &lo, &hi, yield)
+	}
+}
+
+// ascend walks the subtree in order, limited to [lo, hi) when bounds are given, and reports
+// whether the caller still wants more items
+func ascend[K cmp.Ordered, V any](n *TreeNode[K, V], lo, hi *K, yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	aboveLo := lo == nil || n.Key >= *lo
+	belowHi := hi == nil || n.Key < *hi
+	if aboveLo && !ascend(n.Left, lo, hi, yield) {
+		return false
+	}
+	if aboveLo && belowHi && !yield(n.Key, n.Value) {
+		return false
+	}
+	if belowHi {
+		return ascend(n.Right, lo, hi, yield)
+	}
+	return true
+}
+
+// descend walks the subtree in reverse order and reports whether the caller wants more items
+func descend[K cmp.Ordered, V any](n *TreeNode[K, V], yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	return descend(n.Right, yield) && yield(n.Key, n.Value) && descend(n.Left, yield)
+}
@@ -0,0 +1,204 @@
+package trees
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Neighbor is a point found by a KD-tree query: its index in the points the tree was built
+// from and its Euclidean distance to the query
+type Neighbor struct {
+	Index    int
+	Distance float64
+}
+
+// KDTree indexes points in k dimensions for nearest-neighbour, radius and box queries
+type KDTree struct {
+	root   *kdNode
+	points [][]float64
+	dim    int
+}
+
+type kdNode struct {
+	index       int // position of the point in KDTree.points
+	axis        int
+	left, right *kdNode
+}
+
+// BuildKDTree builds a balanced KD-tree by splitting on the median along each axis in turn.
+// The points are referenced, not copied, and must all have the same dimension.
+func BuildKDTree(points [][]float64) (*KDTree, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("cannot build a KD-tree from no points")
+	}
+	dim := len(points[0])
+	for i, p := range points {
+		if len(p) != dim {
+			return nil, fmt.Errorf("point %d has %d dimensions, expected %d", i, len(p), dim)
+		}
+	}
+
+	indices := make([]int, len(points))
+	for i := range indices {
+		indices[i] = i
+	}
+	t := &KDTree{points: points, dim: dim}
+	t.root = t.build(indices, 0)
+	return t, nil
+}
+
+func (t *KDTree) build(indices []int, depth int) *kdNode {
+	if len(indices) == 0 {
+		return nil
+	}
+	axis := depth % t.dim
+	sort.Slice(indices, func(a, b int) bool {
+		return t.points[indices[a]][axis] < t.points[indices[b]][axis]
+	})
+	mid := len(indices) / 2
+	return &kdNode{
+		index: indices[mid],
+		axis:  axis,
+		left:  t.build(indices[:mid], depth+1),
+		right: t.build(indices[mid+1:], depth+1),
+	}
+}
+
+// Len returns the number of indexed points
+func (t *KDTree) Len() int {
+	return len(t.points)
+}
+
+// checkDim reports an error when a query point does not match the tree's dimension
+func (t *KDTree) checkDim(query []float64) error {
+	if len(query) != t.dim {
+		return fmt.Errorf("query has %d dimensions, the tree has %d", len(query), t.dim)
+	}
+	return nil
+}
+
+// Nearest returns the indexed point closest to query
+func (t *KDTree) Nearest(query []float64) (Neighbor, error) {
+	best, err := t.KNearest(query, 1)
+	if err != nil {
+		return Neighbor{}, err
+	}
+	return best[0], nil
+}
+
+// KNearest returns the k points closest to query, nearest first
+func (t *KDTree) KNearest(query []float64, k int) ([]Neighbor, error) {
+	if err := t.checkDim(query); err != nil {
+		return nil, err
+	}
+	if k < 1 {
+		return nil, fmt.Errorf("k must be at least 1, got %d", k)
+	}
+	k = min(k, len(t.points))
+	best := make([]Neighbor, 0, k)
+	t.kNearest(t.root, query, k, &best)
+	return best, nil
+}
+
+// kNearest keeps best sorted by distance and prunes subtrees whose splitting plane is further
+// away than the current k-th neighbour
+func (t *KDTree) kNearest(n *kdNode, query []float64, k int, best *[]Neighbor) {
+	if n == nil {
+		return
+	}
+	d := euclidean(query, t.points[n.index])
+	if len(*best) < k || d < (*best)[len(*best)-1].Distance {
+		pos := sort.Search(len(*best), func(i int) bool { return (*best)[i].Distance > d })
+		if len(*best) < k {
+			*best = append(*best, Neighbor{})
+		}
+		copy((*best)[pos+1:], (*best)[pos:])
+		(*best)[pos] = Neighbor{Index: n.index, Distance: d}
+	}
+
+	diff := query[n.axis] - t.points[n.index][n.axis]
+	near, far := n.left, n.right
+	if diff > 0 {
+		near, far = far, near
+	}
+	t.kNearest(near, query, k, best)
+	if len(*best) < k || math.Abs(diff) < (*best)[len(*best)-1].Distance {
+		t.kNearest(far, query, k, best)
+	}
+}
+
+// Radius returns every point within radius of query, nearest first. This is the
+// neighbourhood query DBSCAN needs.
+func (t *KDTree) Radius(query []float64, radius float64) ([]Neighbor, error) {
+	if err := t.checkDim(query); err != nil {
+		return nil, err
+	}
+	var found []Neighbor
+	t.radius(t.root, query, radius, &found)
+	sort.Slice(found, func(a, b int) bool { return found[a].Distance < found[b].Distance })
+	return found, nil
+}
+
+func (t *KDTree) radius(n *kdNode, query []float64, radius float64, found *[]Neighbor) {
+	if n == nil {
+		return
+	}
+	if d := euclidean(query, t.points[n.index]); d <= radius {
+		*found = append(*found, Neighbor{Index: n.index, Distance: d})
+	}
+	diff := query[n.axis] - t.points[n.index][n.axis]
+	if diff <= radius {
+		t.radius(n.left, query, radius, found)
+	}
+	if diff >= -radius {
+		t.radius(n.right, query, radius, found)
+	}
+}
+
+// Range returns the indices of the points inside the axis-aligned box [lo, hi], inclusive
+func (t *KDTree) Range(lo, hi []float64) ([]int, error) {
+	if err := t.checkDim(lo); err != nil {
+		return nil, err
+	}
+	if err := t.checkDim(hi); err != nil {
+		return nil, err
+	}
+	var found []int
+	t.rangeSearch(t.root, lo, hi, &found)
+	sort.Ints(found)
+	return found, nil
+}
+
+func (t *KDTree) rangeSearch(n *kdNode, lo, hi []float64, found *[]int) {
+	if n == nil {
+		return
+	}
+	p := t.points[n.index]
+	inside := true
+	for d := range p {
+		if p[d] < lo[d] || p[d] > hi[d] {
+			inside = false
+			break
+		}
+	}
+	if inside {
+		*found = append(*found, n.index)
+	}
+	if lo[n.axis] <= p[n.axis] {
+		t.rangeSearch(n.left, lo, hi, found)
+	}
+	if hi[n.axis] >= p[n.axis] {
+		t.rangeSearch(n.right, lo, hi, found)
+	}
+}
+
+// euclidean returns the Euclidean distance between two points
+func euclidean(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
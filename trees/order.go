@@ -0,0 +1,55 @@
+package trees
+
+import "cmp"
+
+// BTreeMin returns the node with the smallest key, or nil for an empty tree
+func BTreeMin[K cmp.Ordered, V any](root *TreeNode[K, V]) *TreeNode[K, V] {
+	if root == nil {
+		return nil
+	}
+	for root.Left != nil {
+		root = root.Left
+	}
+	return root
+}
+
+// BTreeMax returns the node with the largest key, or nil for an empty tree
+func BTreeMax[K cmp.Ordered, V any](root *TreeNode[K, V]) *TreeNode[K, V] {
+	if root == nil {
+		return nil
+	}
+	for root.Right != nil {
+		root = root.Right
+	}
+	return root
+}
+
+// BTreeSuccessor returns the node with the next larger key, or nil if node holds the largest.
+// It follows Parent pointers, so it needs no access to the root.
+func BTreeSuccessor[K cmp.Ordered, V any](node *TreeNode[K, V]) *TreeNode[K, V] {
+	if node == nil {
+		return nil
+	}
+	if node.Right != nil {
+		return BTreeMin(node.Right)
+	}
+	// Climb until we come up from a left child; that parent is the next key
+	for node.Parent != nil && node == node.Parent.Right {
+		node = node.Parent
+	}
+	return node.Parent
+}
+
+// BTreePredecessor returns the node with the next smaller key, or nil if node holds the smallest
+func BTreePredecessor[K cmp.Ordered, V any](node *TreeNode[K, V]) *TreeNode[K, V] {
+	if node == nil {
+		return nil
+	}
+	if node.Left != nil {
+		return BTreeMax(node.Left)
+	}
+	for node.Parent != nil && node == node.Parent.Left {
+		node = node.Parent
+	}
+	return node.Parent
+}
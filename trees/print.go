@@ -0,0 +1,68 @@
+package trees
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// BTreeApplyLevelOrder calls f on every node breadth first: the root, then its children
+// from left to right, then their children, and so on
+func BTreeApplyLevelOrder[K cmp.Ordered, V any](root *TreeNode[K, V], f func(K, V)) {
+	if root == nil {
+		return
+	}
+	queue := []*TreeNode[K, V]{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		f(n.Key, n.Value)
+		if n.Left != nil {
+			queue = append(queue, n.Left)
+		}
+		if n.Right != nil {
+			queue = append(queue, n.Right)
+		}
+	}
+}
+
+// BTreeString draws the tree as indented ASCII, one node per line with its balance factor:
+//
+//	4 (bf -1)
+//	├── L: 1 (bf 0)
+//	└── R: 7 (bf 1)
+//	    └── L: 5 (bf 0)
+func BTreeString[K cmp.Ordered, V any](root *TreeNode[K, V]) string {
+	if root == nil {
+		return "(empty)\n"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v (bf %d)\n", root.Key, BTreeBalanceFactor(root))
+	writeChildren(&sb, root, "")
+	return sb.String()
+}
+
+// writeChildren draws the children of n below it, prefixing each line with the guides of
+// the levels above
+func writeChildren[K cmp.Ordered, V any](sb *strings.Builder, n *TreeNode[K, V], prefix string) {
+	type branch struct {
+		side  string
+		child *TreeNode[K, V]
+	}
+	var children []branch
+	if n.Left != nil {
+		children = append(children, branch{"L", n.Left})
+	}
+	if n.Right != nil {
+		children = append(children, branch{"R", n.Right})
+	}
+
+	for i, c := range children {
+		connector, guide := "├── ", "│   "
+		if i == len(children)-1 {
+			connector, guide = "└── ", "    "
+		}
+		fmt.Fprintf(sb, "%s%s%s: %v (bf %d)\n", prefix, connector, c.side, c.child.Key, BTreeBalanceFactor(c.child))
+		writeChildren(sb, c.child, prefix+guide)
+	}
+}
@@ -0,0 +1,83 @@
+package trees
+
+import (
+	"cmp"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// serialNode mirrors TreeNode without the Parent pointer, which would make the structure
+// cyclic; parents and heights are rebuilt when decoding
+type serialNode[K cmp.Ordered, V any] struct {
+	Key        K                 `json:"key"`
+	Value      V                 `json:"value"`
+	Count      int               `json:"count,omitempty"`
+	Duplicates []V               `json:"duplicates,omitempty"`
+	Left       *serialNode[K, V] `json:"left,omitempty"`
+	Right      *serialNode[K, V] `json:"right,omitempty"`
+}
+
+// serialTree wraps the root so an empty tree encodes too
+type serialTree[K cmp.Ordered, V any] struct {
+	Root *serialNode[K, V] `json:"root"`
+}
+
+func toSerial[K cmp.Ordered, V any](n *TreeNode[K, V]) *serialNode[K, V] {
+	if n == nil {
+		return nil
+	}
+	return &serialNode[K, V]{
+		Key: n.Key, Value: n.Value, Count: n.Count, Duplicates: n.Duplicates,
+		Left: toSerial(n.Left), Right: toSerial(n.Right),
+	}
+}
+
+func fromSerial[K cmp.Ordered, V any](s *serialNode[K, V], parent *TreeNode[K, V]) *TreeNode[K, V] {
+	if s == nil {
+		return nil
+	}
+	n := &TreeNode[K, V]{Key: s.Key, Value: s.Value, Count: s.Count, Duplicates: s.Duplicates, Parent: parent}
+	n.Left = fromSerial(s.Left, n)
+	n.Right = fromSerial(s.Right, n)
+	updateHeight(n)
+	return n
+}
+
+// BTreeMarshalJSON encodes the tree, keeping its shape, as nested JSON objects
+func BTreeMarshalJSON[K cmp.Ordered, V any](root *TreeNode[K, V]) ([]byte, error) {
+	return json.Marshal(serialTree[K, V]{Root: toSerial(root)})
+}
+
+// BTreeUnmarshalJSON decodes a tree written by BTreeMarshalJSON and checks its invariants
+func BTreeUnmarshalJSON[K cmp.Ordered, V any](data []byte) (*TreeNode[K, V], error) {
+	var tree serialTree[K, V]
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("error decoding tree: %v", err)
+	}
+	return restore(tree)
+}
+
+// BTreeEncodeGob writes the tree to w in gob format
+func BTreeEncodeGob[K cmp.Ordered, V any](w io.Writer, root *TreeNode[K, V]) error {
+	return gob.NewEncoder(w).Encode(serialTree[K, V]{Root: toSerial(root)})
+}
+
+// BTreeDecodeGob reads a tree written by BTreeEncodeGob and checks its invariants
+func BTreeDecodeGob[K cmp.Ordered, V any](r io.Reader) (*TreeNode[K, V], error) {
+	var tree serialTree[K, V]
+	if err := gob.NewDecoder(r).Decode(&tree); err != nil {
+		return nil, fmt.Errorf("error decoding tree: %v", err)
+	}
+	return restore(tree)
+}
+
+// restore rebuilds the linked tree and rejects data that breaks ordering or balance
+func restore[K cmp.Ordered, V any](tree serialTree[K, V]) (*TreeNode[K, V], error) {
+	root := fromSerial(tree.Root, nil)
+	if err := BTreeCheck(root); err != nil {
+		return nil, fmt.Errorf("invalid tree: %v", err)
+	}
+	return root, nil
+}
@@ -0,0 +1,117 @@
+package trees
+
+import (
+	"cmp"
+	"iter"
+	"sync"
+)
+
+// SyncTree is a balanced tree that is safe for concurrent use: any number of goroutines
+// may search it while others insert or delete. Reads share a lock and writes take it
+// exclusively, and nodes are never handed out, so callers cannot race on them.
+type SyncTree[K cmp.Ordered, V any] struct {
+	mu   sync.RWMutex
+	root *TreeNode[K, V]
+	size int
+}
+
+// NewSyncTree returns an empty concurrency-safe tree
+func NewSyncTree[K cmp.Ordered, V any]() *SyncTree[K, V] {
+	return &SyncTree[K, V]{}
+}
+
+// Insert adds key with its value, handling an existing key according to policy
+func (t *SyncTree[K, V]) Insert(key K, value V, policy DuplicatePolicy) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	existed := BTreeSearchItem(t.root, key) != nil
+	root, err := BTreeInsertWithPolicy(t.root, key, value, policy)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	if !existed {
+		t.size++
+	}
+	return nil
+}
+
+// Delete removes key and reports whether it was present
+func (t *SyncTree[K, V]) Delete(key K) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if BTreeSearchItem(t.root, key) == nil {
+		return false
+	}
+	t.root = BTreeDelete(t.root, key)
+	t.size--
+	return true
+}
+
+// Search returns the value stored under key, how many times it was inserted, and whether
+// it was found
+func (t *SyncTree[K, V]) Search(key K) (V, int, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if node := BTreeSearchItem(t.root, key); node != nil {
+		return node.Value, node.Count, true
+	}
+	var zero V
+	return zero, 0, false
+}
+
+// Len returns the number of distinct keys
+func (t *SyncTree[K, V]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.size
+}
+
+// Min returns the smallest key, and false if the tree is empty
+func (t *SyncTree[K, V]) Min() (K, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if node := BTreeMin(t.root); node != nil {
+		return node.Key, true
+	}
+	var zero K
+	return zero, false
+}
+
+// Max returns the largest key, and false if the tree is empty
+func (t *SyncTree[K, V]) Max() (K, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if node := BTreeMax(t.root); node != nil {
+		return node.Key, true
+	}
+	var zero K
+	return zero, false
+}
+
+// AscendRange yields the keys in [lo, hi) in ascending order. The read lock is held for
+// the whole loop, so writers wait until it ends; the loop body must not write to the tree.
+func (t *SyncTree[K, V]) AscendRange(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		for k, v := range BTreeAscendRange(t.root, lo, hi) {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Ascend yields every key in ascending order under the read lock, like AscendRange
+func (t *SyncTree[K, V]) Ascend() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		for k, v := range BTreeAscend(t.root) {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}